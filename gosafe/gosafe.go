@@ -4,15 +4,18 @@ package gosafe
 import (
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/puresnr/go/trimpath"
 	"os"
 	"runtime"
+	"runtime/debug"
 )
 
+// PrintPanicStack 向标准错误输出当前的调用栈和 extras, 文件路径按 trimpath.SetMode 设置的方式裁剪
 func PrintPanicStack(extras ...interface{}) {
 	i := 0
 	funcName, file, line, ok := runtime.Caller(i)
 	for ok {
-		fmt.Fprintf(os.Stderr, "frame %v:[func:%v,file:%v,line:%v]\n", i, runtime.FuncForPC(funcName).Name(), file, line)
+		fmt.Fprintf(os.Stderr, "frame %v:[func:%v,file:%v,line:%v]\n", i, runtime.FuncForPC(funcName).Name(), trimpath.Path(file), line)
 		i++
 		funcName, file, line, ok = runtime.Caller(i)
 	}
@@ -28,12 +31,47 @@ func onExit(extras ...interface{}) {
 	}
 }
 
+// PanicError 表示被 gosafe 捕获的 panic
+type PanicError struct {
+	Value any    // panic 时传入的值
+	Stack []byte // panic 时 debug.Stack 格式的调用栈, 文件路径按 trimpath.SetMode 设置的方式裁剪
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap 当 panic 的值本身是 error 时返回该 error, 使 errors.Is/As 可以穿透 PanicError
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Run 在当前协程中同步执行 f, panic 时打印调用栈, 并把 panic 转换为 *PanicError 返回; f 正常返回时返回 nil
+// 入参:
+//
+//	f: 待执行函数
+func Run(f func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			PrintPanicStack()
+			fmt.Fprintf(os.Stderr, "<recover from panic: %s>\n", v)
+
+			err = &PanicError{Value: v, Stack: trimpath.Stack(debug.Stack())}
+		}
+	}()
+
+	f()
+
+	return nil
+}
+
 // Go 用于执行无入参的函数, panic 时不会开启新的协程重新执行该函数
 // 入参:
 //
 //	f: 待执行函数
 func Go(f func()) {
+	r := begin(0)
 	go func() {
+		defer end(r)
 		defer onExit()
 
 		f()
@@ -46,41 +84,53 @@ func Go(f func()) {
 //	f: 待执行函数
 //	p: 待执行函数使用的入参, 如果入参数量多于 1 个, 需要把所有入参包装为一个结构体, 使用该结构体作为入参
 func GoP[T any](f func(T), p T) {
+	r := begin(0)
 	go func() {
+		defer end(r)
 		defer onExit()
 
 		f(p)
 	}()
 }
 
-func onExitR(f func(), extras ...interface{}) {
+func onExitR(f func(), r *record, extras ...interface{}) {
 	if err := recover(); err != nil {
 		PrintPanicStack(extras)
 		fmt.Fprintf(os.Stderr, "<recover from panic: %s>\n", err)
 
-		GoR(f)
+		restart(r)
+		goR(f, r)
+		return
 	}
+
+	end(r)
 }
 
 // GoR 用于执行无入参的函数, panic 时会开启新的协程重新执行该函数
 // 入参:
 //
 //	f: 待执行函数
-func GoR(f func()) {
+func GoR(f func()) { goR(f, begin(0)) }
+
+func goR(f func(), r *record) {
 	go func() {
-		defer onExitR(f)
+		defer onExitR(f, r)
 
 		f()
 	}()
 }
 
-func onExitPR[T any](f func(T), p T, extras ...interface{}) {
+func onExitPR[T any](f func(T), p T, r *record, extras ...interface{}) {
 	if err := recover(); err != nil {
 		PrintPanicStack(extras)
 		fmt.Fprintf(os.Stderr, "<recover from panic: %s>\n", err)
 
-		GoPR(f, p)
+		restart(r)
+		goPR(f, p, r)
+		return
 	}
+
+	end(r)
 }
 
 // GoPR 用于执行有入参的函数, panic 时会开启新的协程重新执行该函数
@@ -88,9 +138,11 @@ func onExitPR[T any](f func(T), p T, extras ...interface{}) {
 //
 //	f: 待执行函数
 //	p: 待执行函数使用的入参, 如果入参数量多于 1 个, 需要把所有入参包装为一个结构体, 使用该结构体作为入参
-func GoPR[T any](f func(T), p T) {
+func GoPR[T any](f func(T), p T) { goPR(f, p, begin(0)) }
+
+func goPR[T any](f func(T), p T, r *record) {
 	go func() {
-		defer onExitPR(f, p)
+		defer onExitPR(f, p, r)
 
 		f(p)
 	}()
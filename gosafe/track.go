@@ -0,0 +1,181 @@
+package gosafe
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/trimpath"
+	"go.uber.org/atomic"
+)
+
+// GoroutineInfo 描述一个通过 gosafe 启动且仍在运行的协程
+type GoroutineInfo struct {
+	ID    uint64    // gosafe 内部分配的序号, 单调递增, 并非 runtime 的 goid
+	Func  string    // 启动该协程的函数名
+	File  string    // 启动位置所在文件, 按 trimpath.SetMode 设置的方式裁剪
+	Line  int       // 启动位置所在行
+	Start time.Time // 启动时间, GoR/GoPR 在 panic 后重启时会刷新
+}
+
+// String 返回 "func (file:line) running for xxx" 形式的描述
+func (g GoroutineInfo) String() string {
+	return fmt.Sprintf("goroutine#%d %s (%s:%d) running for %v", g.ID, g.Func, g.File, g.Line, time.Since(g.Start))
+}
+
+type record struct {
+	info GoroutineInfo
+}
+
+var (
+	tracking atomic.Bool
+	running  atomic.Int64
+	seq      atomic.Uint64
+
+	recordsMu sync.Mutex
+	records   = make(map[uint64]*record)
+)
+
+// EnableTrack 开启或关闭协程追踪, 返回之前的开关状态.
+// 开启后, 每个通过 gosafe 启动的协程都会记录启动位置和启动时间, 可以通过 Running/LongRunning/Dump 查看;
+// 关闭时只维护运行中的协程数量, 开销可以忽略.
+// 开关只影响之后启动的协程, 已经在运行的协程不会被补录.
+func EnableTrack(on bool) bool { return tracking.Swap(on) }
+
+// TrackEnabled 返回当前是否开启了协程追踪
+func TrackEnabled() bool { return tracking.Load() }
+
+// Count 返回当前通过 gosafe 启动且尚未退出的协程数量, 无论是否开启追踪都有效
+func Count() int64 { return running.Load() }
+
+// begin 在启动协程前调用, skip 为相对于调用 begin 的函数而言需要跳过的栈帧数
+func begin(skip int) *record {
+	running.Inc()
+
+	if !tracking.Load() {
+		return nil
+	}
+
+	r := &record{info: GoroutineInfo{ID: seq.Inc(), Start: time.Now()}}
+	if pc, file, line, ok := runtime.Caller(skip + 2); ok {
+		r.info.File, r.info.Line = trimpath.Path(file), line
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			r.info.Func = fn.Name()
+		}
+	}
+
+	recordsMu.Lock()
+	records[r.info.ID] = r
+	recordsMu.Unlock()
+
+	return r
+}
+
+// restart 在 GoR/GoPR 重启协程时调用, 保留启动位置, 只刷新启动时间
+func restart(r *record) {
+	if r == nil {
+		return
+	}
+
+	recordsMu.Lock()
+	r.info.Start = time.Now()
+	recordsMu.Unlock()
+}
+
+// end 在协程退出时调用
+func end(r *record) {
+	running.Dec()
+
+	if r == nil {
+		return
+	}
+
+	recordsMu.Lock()
+	delete(records, r.info.ID)
+	recordsMu.Unlock()
+}
+
+// Running 返回所有被追踪且仍在运行的协程, 按启动时间从早到晚排序
+func Running() []GoroutineInfo { return LongRunning(0) }
+
+// LongRunning 返回所有被追踪且已经运行超过 d 的协程, 按启动时间从早到晚排序
+func LongRunning(d time.Duration) []GoroutineInfo {
+	return collect(func(info GoroutineInfo) bool { return time.Since(info.Start) >= d })
+}
+
+func collect(filter func(GoroutineInfo) bool) []GoroutineInfo {
+	recordsMu.Lock()
+	infos := make([]GoroutineInfo, 0, len(records))
+	for _, r := range records {
+		if filter(r.info) {
+			infos = append(infos, r.info)
+		}
+	}
+	recordsMu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	return infos
+}
+
+// Dump 把所有被追踪且已经运行超过 d 的协程逐行写入 w, 返回写入的协程数量
+func Dump(w io.Writer, d time.Duration) int {
+	infos := LongRunning(d)
+	for _, info := range infos {
+		fmt.Fprintln(w, info.String())
+	}
+
+	return len(infos)
+}
+
+// TB 是 testing.TB 的子集, 用于 TrackTest, 避免 gosafe 依赖 testing 包
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+// TrackTest 用于测试中检查协程泄漏: 调用时开启追踪, 在测试结束时等待本测试期间启动的 gosafe 协程退出,
+// 如果等待 grace(默认 1 秒) 后仍有协程在运行, 则把它们的启动位置报告为测试失败. 测试结束后恢复之前的追踪开关.
+// 调用方式: 在测试函数开头调用 gosafe.TrackTest(t) 即可.
+// 注意: 并行执行的测试之间无法区分各自启动的协程.
+func TrackTest(t TB, grace ...time.Duration) {
+	t.Helper()
+
+	wait := time.Second
+	if len(grace) != 0 {
+		wait = grace[0]
+	}
+
+	prev := EnableTrack(true)
+	since := seq.Load()
+
+	t.Cleanup(func() {
+		t.Helper()
+		defer EnableTrack(prev)
+
+		leaked := func() []GoroutineInfo {
+			return collect(func(info GoroutineInfo) bool { return info.ID > since })
+		}
+
+		deadline := time.Now().Add(wait)
+		for {
+			infos := leaked()
+			if len(infos) == 0 {
+				return
+			}
+
+			if time.Now().After(deadline) {
+				for _, info := range infos {
+					t.Errorf("gosafe: leaked %s", info)
+				}
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package gosafe
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/trimpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB records failures instead of failing the real test.
+type fakeTB struct {
+	mu       sync.Mutex
+	cleanups []func()
+	errs     []string
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestTrack(t *testing.T) {
+	t.Run("count without tracking", func(t *testing.T) {
+		prev := EnableTrack(false)
+		defer EnableTrack(prev)
+
+		base := Count()
+		stop := make(chan struct{})
+		Go(func() { <-stop })
+		GoP(func(c chan struct{}) { <-c }, stop)
+		assert.Equal(t, base+2, Count())
+		assert.Empty(t, Running())
+
+		close(stop)
+		assert.Eventually(t, func() bool { return Count() == base }, time.Second, time.Millisecond)
+	})
+
+	t.Run("launch site and long running", func(t *testing.T) {
+		prev := EnableTrack(true)
+		defer EnableTrack(prev)
+
+		stop := make(chan struct{})
+		Go(func() { <-stop })
+		time.Sleep(20 * time.Millisecond)
+
+		infos := LongRunning(10 * time.Millisecond)
+		require.Len(t, infos, 1)
+		assert.True(t, strings.HasSuffix(infos[0].File, "track_test.go"))
+		assert.Contains(t, infos[0].Func, "TestTrack")
+		assert.Empty(t, LongRunning(time.Hour))
+
+		var buf bytes.Buffer
+		assert.Equal(t, 1, Dump(&buf, 0))
+		assert.Contains(t, buf.String(), "track_test.go")
+
+		close(stop)
+		assert.Eventually(t, func() bool { return len(Running()) == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("trimmed launch site", func(t *testing.T) {
+		prev := EnableTrack(true)
+		defer EnableTrack(prev)
+		defer trimpath.SetMode(trimpath.SetMode(trimpath.ModuleRoot))
+
+		stop := make(chan struct{})
+		Go(func() { <-stop })
+
+		infos := Running()
+		require.Len(t, infos, 1)
+		assert.Equal(t, "gosafe/track_test.go", infos[0].File)
+
+		var pe *PanicError
+		require.ErrorAs(t, Run(func() { panic("boom") }), &pe)
+		assert.Contains(t, string(pe.Stack), "\tgosafe/track_test.go:")
+
+		close(stop)
+		assert.Eventually(t, func() bool { return len(Running()) == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("restart keeps record", func(t *testing.T) {
+		prev := EnableTrack(true)
+		defer EnableTrack(prev)
+
+		base := Count()
+		var once sync.Once
+		stop := make(chan struct{})
+		GoR(func() {
+			once.Do(func() { panic("boom") })
+			<-stop
+		})
+
+		assert.Eventually(t, func() bool { return len(Running()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, base+1, Count())
+
+		close(stop)
+		assert.Eventually(t, func() bool { return Count() == base && len(Running()) == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("TrackTest reports leaks", func(t *testing.T) {
+		ft := &fakeTB{}
+		TrackTest(ft, 20*time.Millisecond)
+
+		stop := make(chan struct{})
+		defer close(stop)
+		Go(func() { <-stop })
+
+		ft.finish()
+		require.Len(t, ft.errs, 1)
+		assert.Contains(t, ft.errs[0], "track_test.go")
+	})
+
+	t.Run("TrackTest passes", func(t *testing.T) {
+		ft := &fakeTB{}
+		TrackTest(ft, time.Second)
+
+		Go(func() { time.Sleep(10 * time.Millisecond) })
+
+		ft.finish()
+		assert.Empty(t, ft.errs)
+	})
+}
@@ -0,0 +1,294 @@
+package gosync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/puresnr/go/perror/ecode"
+)
+
+// ErrorCircuitOpen 在熔断器处于打开状态, 或半开状态下试探请求已满时由 Execute 返回, 可以通过 errors.Is 判断
+var ErrorCircuitOpen = ecode.New("circuit breaker open")
+
+// BreakerState 是熔断器的状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭: 请求正常执行, 并统计失败情况
+	BreakerOpen                         // 打开: 请求直接返回 ErrorCircuitOpen, 冷却时间过后进入半开
+	BreakerHalfOpen                     // 半开: 放行有限的试探请求, 全部成功则关闭, 任一失败则重新打开
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type breakerOptions struct {
+	consecutiveFailures uint
+	failureRate         float64
+	minRequests         uint
+	window              time.Duration
+	coolDown            time.Duration
+	halfOpenRequests    uint
+	onStateChange       func(from, to BreakerState)
+	isFailure           func(error) bool
+}
+
+// BreakerOption 用于配置 CircuitBreaker
+type BreakerOption func(*breakerOptions)
+
+// WithConsecutiveFailures 设置连续失败多少次后打开熔断器, 默认 5, 为 0 时不按连续失败次数打开
+func WithConsecutiveFailures(n uint) BreakerOption {
+	return func(o *breakerOptions) { o.consecutiveFailures = n }
+}
+
+// WithFailureRate 设置按失败率打开熔断器: 统计窗口内请求数不少于 minRequests 且失败率不低于 rate(0~1) 时打开.
+// 默认不按失败率打开.
+func WithFailureRate(rate float64, minRequests uint) BreakerOption {
+	return func(o *breakerOptions) { o.failureRate, o.minRequests = rate, minRequests }
+}
+
+// WithWindow 设置关闭状态下统计数据的清零周期, 小于等于 0 时只在状态变化时清零, 默认 1 分钟
+func WithWindow(d time.Duration) BreakerOption { return func(o *breakerOptions) { o.window = d } }
+
+// WithCoolDown 设置打开状态持续多久后进入半开状态, 默认 30 秒
+func WithCoolDown(d time.Duration) BreakerOption { return func(o *breakerOptions) { o.coolDown = d } }
+
+// WithHalfOpenRequests 设置半开状态下放行的试探请求数量, 这些请求全部成功后关闭熔断器, 默认 1
+func WithHalfOpenRequests(n uint) BreakerOption {
+	return func(o *breakerOptions) { o.halfOpenRequests = max(n, 1) }
+}
+
+// WithStateChange 设置状态变化时的回调, 回调在触发状态变化的调用中同步执行, 且不持有熔断器的锁
+func WithStateChange(f func(from, to BreakerState)) BreakerOption {
+	return func(o *breakerOptions) { o.onStateChange = f }
+}
+
+// WithIsFailure 设置如何判断 Execute 中函数返回的错误是否算作失败, 默认 err != nil 即为失败.
+// 比如参数错误之类的业务错误通常不应该导致熔断.
+func WithIsFailure(f func(error) bool) BreakerOption {
+	return func(o *breakerOptions) { o.isFailure = f }
+}
+
+type breakerCounts struct {
+	requests            uint
+	failures            uint
+	consecutiveFailures uint
+	consecutiveSuccess  uint
+}
+
+// CircuitBreaker 是熔断器, 用于在下游持续失败时快速失败, 避免继续压垮下游.
+// 必须通过 NewCircuitBreaker 创建, 可以并发使用.
+type CircuitBreaker struct {
+	opts breakerOptions
+
+	mu     sync.Mutex
+	state  BreakerState
+	gen    uint64 // 每次状态变化或统计清零时递增, 用于丢弃旧周期中请求的结果
+	counts breakerCounts
+	expiry time.Time // 关闭状态下为统计清零的时间, 打开状态下为进入半开的时间
+	trials uint      // 半开状态下已经放行的试探请求数量
+}
+
+// NewCircuitBreaker 创建一个处于关闭状态的熔断器
+func NewCircuitBreaker(opts ...BreakerOption) *CircuitBreaker {
+	o := breakerOptions{
+		consecutiveFailures: 5,
+		window:              time.Minute,
+		coolDown:            30 * time.Second,
+		halfOpenRequests:    1,
+		isFailure:           func(err error) bool { return err != nil },
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cb := &CircuitBreaker{opts: o}
+	cb.toGen(time.Now())
+
+	return cb
+}
+
+type breakerChange struct{ from, to BreakerState }
+
+// toGen 开启新的统计周期, 调用方需持有 mu
+func (cb *CircuitBreaker) toGen(now time.Time) {
+	cb.gen++
+	cb.counts = breakerCounts{}
+	cb.trials = 0
+
+	cb.expiry = time.Time{}
+	switch cb.state {
+	case BreakerClosed:
+		if cb.opts.window > 0 {
+			cb.expiry = now.Add(cb.opts.window)
+		}
+	case BreakerOpen:
+		cb.expiry = now.Add(cb.opts.coolDown)
+	}
+}
+
+// setState 调用方需持有 mu
+func (cb *CircuitBreaker) setState(to BreakerState, now time.Time, changes []breakerChange) []breakerChange {
+	if cb.state == to {
+		return changes
+	}
+
+	from := cb.state
+	cb.state = to
+	cb.toGen(now)
+
+	return append(changes, breakerChange{from: from, to: to})
+}
+
+// current 根据时间推进状态, 调用方需持有 mu
+func (cb *CircuitBreaker) current(now time.Time, changes []breakerChange) []breakerChange {
+	switch cb.state {
+	case BreakerClosed:
+		if !cb.expiry.IsZero() && !now.Before(cb.expiry) {
+			cb.toGen(now)
+		}
+	case BreakerOpen:
+		if !now.Before(cb.expiry) {
+			changes = cb.setState(BreakerHalfOpen, now, changes)
+		}
+	}
+
+	return changes
+}
+
+func (cb *CircuitBreaker) notify(changes []breakerChange) {
+	if cb.opts.onStateChange == nil {
+		return
+	}
+
+	for _, c := range changes {
+		cb.opts.onStateChange(c.from, c.to)
+	}
+}
+
+// State 返回熔断器当前的状态
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	changes := cb.current(time.Now(), nil)
+	state := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+
+	return state
+}
+
+// Reset 把熔断器重置为关闭状态并清空统计数据
+func (cb *CircuitBreaker) Reset() {
+	now := time.Now()
+
+	cb.mu.Lock()
+	changes := cb.setState(BreakerClosed, now, nil)
+	cb.toGen(now)
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+}
+
+func (cb *CircuitBreaker) before() (uint64, error) {
+	cb.mu.Lock()
+	changes := cb.current(time.Now(), nil)
+
+	var err error
+	switch cb.state {
+	case BreakerOpen:
+		err = ErrorCircuitOpen
+	case BreakerHalfOpen:
+		if cb.trials >= cb.opts.halfOpenRequests {
+			err = ErrorCircuitOpen
+		} else {
+			cb.trials++
+		}
+	}
+	if err == nil {
+		cb.counts.requests++
+	}
+	gen := cb.gen
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+
+	return gen, err
+}
+
+func (cb *CircuitBreaker) after(gen uint64, failed bool) {
+	now := time.Now()
+
+	cb.mu.Lock()
+	changes := cb.current(now, nil)
+	if gen != cb.gen {
+		cb.mu.Unlock()
+		cb.notify(changes)
+		return
+	}
+
+	c := &cb.counts
+	if failed {
+		c.failures++
+		c.consecutiveFailures++
+		c.consecutiveSuccess = 0
+	} else {
+		c.consecutiveFailures = 0
+		c.consecutiveSuccess++
+	}
+
+	switch cb.state {
+	case BreakerClosed:
+		if failed && cb.shouldTrip() {
+			changes = cb.setState(BreakerOpen, now, changes)
+		}
+	case BreakerHalfOpen:
+		if failed {
+			changes = cb.setState(BreakerOpen, now, changes)
+		} else if c.consecutiveSuccess >= cb.opts.halfOpenRequests {
+			changes = cb.setState(BreakerClosed, now, changes)
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.notify(changes)
+}
+
+// shouldTrip 调用方需持有 mu
+func (cb *CircuitBreaker) shouldTrip() bool {
+	c := cb.counts
+	if cb.opts.consecutiveFailures > 0 && c.consecutiveFailures >= cb.opts.consecutiveFailures {
+		return true
+	}
+
+	return cb.opts.failureRate > 0 && c.requests >= max(cb.opts.minRequests, 1) &&
+		float64(c.failures)/float64(c.requests) >= cb.opts.failureRate
+}
+
+// Execute 在熔断器允许时执行 f 并根据结果更新统计数据, 返回 f 的错误; 不允许时不执行 f, 直接返回 ErrorCircuitOpen.
+// f 中的 panic 会被 gosafe 捕获, 算作一次失败, 并以 *gosafe.PanicError 的形式返回.
+func (cb *CircuitBreaker) Execute(f func() error) error {
+	gen, err := cb.before()
+	if err != nil {
+		return err
+	}
+
+	if perr := gosafe.Run(func() { err = f() }); perr != nil {
+		err = perr
+	}
+
+	cb.after(gen, cb.opts.isFailure(err))
+
+	return err
+}
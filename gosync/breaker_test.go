@@ -0,0 +1,64 @@
+package gosync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/perror/ecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	fail := errors.New("fail")
+	failing := func() error { return fail }
+	ok := func() error { return nil }
+
+	t.Run("consecutive failures", func(t *testing.T) {
+		var changes []string
+		cb := NewCircuitBreaker(WithConsecutiveFailures(3), WithCoolDown(20*time.Millisecond),
+			WithStateChange(func(from, to BreakerState) { changes = append(changes, from.String()+"->"+to.String()) }))
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, fail, cb.Execute(failing))
+		}
+		assert.Equal(t, BreakerOpen, cb.State())
+
+		err := cb.Execute(ok)
+		assert.ErrorIs(t, err, ErrorCircuitOpen)
+		assert.ErrorIs(t, err, ecode.New("circuit breaker open"))
+
+		time.Sleep(25 * time.Millisecond)
+		assert.Equal(t, BreakerHalfOpen, cb.State())
+		assert.Equal(t, fail, cb.Execute(failing))
+		assert.Equal(t, BreakerOpen, cb.State())
+
+		time.Sleep(25 * time.Millisecond)
+		assert.NoError(t, cb.Execute(ok))
+		assert.Equal(t, BreakerClosed, cb.State())
+
+		assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}, changes)
+	})
+
+	t.Run("failure rate", func(t *testing.T) {
+		cb := NewCircuitBreaker(WithConsecutiveFailures(0), WithFailureRate(0.5, 4))
+		assert.NoError(t, cb.Execute(ok))
+		assert.Error(t, cb.Execute(failing))
+		assert.NoError(t, cb.Execute(ok))
+		assert.Equal(t, BreakerClosed, cb.State())
+		assert.Error(t, cb.Execute(failing))
+		assert.Equal(t, BreakerOpen, cb.State())
+
+		cb.Reset()
+		assert.Equal(t, BreakerClosed, cb.State())
+	})
+
+	t.Run("panic and classifier", func(t *testing.T) {
+		cb := NewCircuitBreaker(WithConsecutiveFailures(1), WithIsFailure(func(err error) bool { return err != nil && err != fail }))
+		assert.Equal(t, fail, cb.Execute(failing))
+		assert.Equal(t, BreakerClosed, cb.State())
+
+		assert.Error(t, cb.Execute(func() error { panic("boom") }))
+		assert.Equal(t, BreakerOpen, cb.State())
+	})
+}
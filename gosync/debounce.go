@@ -0,0 +1,211 @@
+package gosync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+type edgeOptions struct {
+	leading  bool
+	trailing bool
+	maxWait  time.Duration
+}
+
+// EdgeOption 用于配置 Debouncer 和 Throttler
+type EdgeOption func(*edgeOptions)
+
+// WithLeading 设置是否在一组触发的开始时立即执行
+func WithLeading(on bool) EdgeOption { return func(o *edgeOptions) { o.leading = on } }
+
+// WithTrailing 设置是否在一组触发的结束时执行
+func WithTrailing(on bool) EdgeOption { return func(o *edgeOptions) { o.trailing = on } }
+
+// WithMaxWait 设置 Debouncer 从一组触发开始到执行的最长等待时间, 避免持续触发时一直不执行, 小于等于 0 时不限制.
+// 对 Throttler 无效.
+func WithMaxWait(d time.Duration) EdgeOption { return func(o *edgeOptions) { o.maxWait = d } }
+
+// edge 是 Debouncer 和 Throttler 的公共部分: 维护当前的定时器、是否有待执行的触发以及停止状态
+type edge struct {
+	f    func()
+	opts edgeOptions
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	gen     uint64    // 每次重新设置定时器时递增, 用于忽略过期定时器的回调
+	pending bool      // 上一次执行之后是否还有触发
+	start   time.Time // 当前这组触发的开始时间, 为零值时表示没有进行中的触发, 只有 Debouncer 使用
+	stopped bool
+}
+
+// schedule 在 d 之后调用 fire, 调用方需持有 mu
+func (e *edge) schedule(d time.Duration, fire func()) {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+
+	e.gen++
+	gen := e.gen
+	e.timer = time.AfterFunc(d, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if gen == e.gen && !e.stopped {
+			fire()
+		}
+	})
+}
+
+// reset 结束当前的一组触发, 调用方需持有 mu
+func (e *edge) reset() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	e.gen++
+	e.pending = false
+	e.start = time.Time{}
+}
+
+// invoke 通过 gosafe 异步执行 f, 调用方需持有 mu
+func (e *edge) invoke() {
+	e.pending = false
+	gosafe.Go(e.f)
+}
+
+// Flush 如果有尚未执行的触发, 立即在当前协程中执行一次(panic 由 gosafe 捕获并返回), 并结束当前的一组触发
+func (e *edge) Flush() error {
+	e.mu.Lock()
+	pending := e.pending && !e.stopped
+	e.reset()
+	e.mu.Unlock()
+
+	if !pending {
+		return nil
+	}
+
+	return gosafe.Run(e.f)
+}
+
+// Stop 取消尚未执行的触发, 之后的 Trigger 都会被忽略
+func (e *edge) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stopped = true
+	e.reset()
+}
+
+// Pending 返回是否有尚未执行的触发
+func (e *edge) Pending() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.pending
+}
+
+// Debouncer 用于合并连续的触发: 距离上一次触发超过 wait 后才执行一次 f.
+// 默认只在一组触发结束时执行(trailing), 可以通过 WithLeading 在开始时也执行一次, 通过 WithMaxWait 限制最长等待时间.
+// f 总是通过 gosafe 执行, panic 不会影响调用方. 必须通过 NewDebouncer 创建, 可以并发使用.
+type Debouncer struct {
+	edge
+	wait time.Duration
+}
+
+// NewDebouncer 创建一个 Debouncer
+func NewDebouncer(wait time.Duration, f func(), opts ...EdgeOption) *Debouncer {
+	d := &Debouncer{edge: edge{f: f, opts: edgeOptions{trailing: true}}, wait: wait}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+
+	return d
+}
+
+// Trigger 触发一次
+func (d *Debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	now := time.Now()
+	if d.start.IsZero() {
+		d.start = now
+		if d.opts.leading {
+			d.invoke()
+		} else {
+			d.pending = true
+		}
+	} else {
+		d.pending = true
+	}
+
+	delay := d.wait
+	if d.opts.maxWait > 0 {
+		delay = min(delay, d.start.Add(d.opts.maxWait).Sub(now))
+	}
+	d.schedule(delay, d.fire)
+}
+
+// fire 在一组触发结束时调用, 调用方需持有 mu
+func (d *Debouncer) fire() {
+	if d.pending && d.opts.trailing {
+		d.invoke()
+	}
+	d.reset()
+}
+
+// Throttler 用于限制执行频率: 每 interval 内最多执行一次 f.
+// 默认在一段时间的开始(leading) 和结束(trailing) 时都会执行, 可以通过 WithLeading/WithTrailing 关闭其中之一.
+// f 总是通过 gosafe 执行, panic 不会影响调用方. 必须通过 NewThrottler 创建, 可以并发使用.
+type Throttler struct {
+	edge
+	interval time.Duration
+}
+
+// NewThrottler 创建一个 Throttler
+func NewThrottler(interval time.Duration, f func(), opts ...EdgeOption) *Throttler {
+	t := &Throttler{edge: edge{f: f, opts: edgeOptions{leading: true, trailing: true}}, interval: interval}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+
+	return t
+}
+
+// Trigger 触发一次
+func (t *Throttler) Trigger() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+
+	if t.timer != nil {
+		t.pending = true
+		return
+	}
+
+	if t.opts.leading {
+		t.invoke()
+	} else {
+		t.pending = true
+	}
+	t.schedule(t.interval, t.fire)
+}
+
+// fire 在一个 interval 结束时调用, 调用方需持有 mu.
+// 结束时执行的那一次同样会开启新的 interval, 保证两次执行之间的间隔不小于 interval.
+func (t *Throttler) fire() {
+	if t.pending && t.opts.trailing {
+		t.invoke()
+		t.schedule(t.interval, t.fire)
+		return
+	}
+	t.reset()
+}
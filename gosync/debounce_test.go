@@ -0,0 +1,80 @@
+package gosync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestDebouncer(t *testing.T) {
+	t.Run("trailing", func(t *testing.T) {
+		var n atomic.Int64
+		d := NewDebouncer(20*time.Millisecond, func() { n.Inc() })
+		for i := 0; i < 5; i++ {
+			d.Trigger()
+			time.Sleep(5 * time.Millisecond)
+		}
+		assert.Equal(t, int64(0), n.Load())
+		assert.Eventually(t, func() bool { return n.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, int64(1), n.Load())
+	})
+
+	t.Run("leading only", func(t *testing.T) {
+		var n atomic.Int64
+		d := NewDebouncer(20*time.Millisecond, func() { n.Inc() }, WithLeading(true), WithTrailing(false))
+		d.Trigger()
+		d.Trigger()
+		assert.Eventually(t, func() bool { return n.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, int64(1), n.Load())
+	})
+
+	t.Run("max wait", func(t *testing.T) {
+		var n atomic.Int64
+		d := NewDebouncer(20*time.Millisecond, func() { n.Inc() }, WithMaxWait(30*time.Millisecond))
+		defer d.Stop()
+		for i := 0; i < 10; i++ {
+			d.Trigger()
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.GreaterOrEqual(t, n.Load(), int64(2))
+	})
+
+	t.Run("flush and stop", func(t *testing.T) {
+		var n atomic.Int64
+		d := NewDebouncer(time.Hour, func() { n.Inc() })
+		d.Trigger()
+		assert.True(t, d.Pending())
+		assert.NoError(t, d.Flush())
+		assert.Equal(t, int64(1), n.Load())
+		assert.NoError(t, d.Flush())
+		assert.Equal(t, int64(1), n.Load())
+
+		d.Stop()
+		d.Trigger()
+		assert.False(t, d.Pending())
+	})
+
+	t.Run("flush recovers panic", func(t *testing.T) {
+		d := NewDebouncer(time.Hour, func() { panic("boom") })
+		d.Trigger()
+		assert.Error(t, d.Flush())
+	})
+}
+
+func TestThrottler(t *testing.T) {
+	var n atomic.Int64
+	th := NewThrottler(30*time.Millisecond, func() { n.Inc() })
+	defer th.Stop()
+
+	th.Trigger()
+	th.Trigger()
+	th.Trigger()
+	assert.Eventually(t, func() bool { return n.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return n.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(70 * time.Millisecond)
+	assert.Equal(t, int64(2), n.Load())
+}
@@ -0,0 +1,258 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/puresnr/go/gosafe"
+	"go.uber.org/atomic"
+)
+
+var ErrorBusClosed = errors.New("event bus closed")
+
+// OverflowPolicy 决定订阅者缓冲区已满时 Publish 的行为
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞发布者, 直到缓冲区有空位
+	OverflowDropOldest                       // 丢弃缓冲区中最早的事件, 放入新事件
+	OverflowDropNewest                       // 丢弃新事件
+)
+
+type subscribeOptions struct {
+	buffer   int
+	overflow OverflowPolicy
+}
+
+// SubscribeOption 用于配置订阅者
+type SubscribeOption func(*subscribeOptions)
+
+// WithBuffer 设置订阅者的缓冲区大小, 默认 64; OverflowDropOldest 时至少为 1
+func WithBuffer(n int) SubscribeOption { return func(o *subscribeOptions) { o.buffer = max(n, 0) } }
+
+// WithOverflow 设置订阅者缓冲区已满时的处理方式, 默认 OverflowBlock
+func WithOverflow(p OverflowPolicy) SubscribeOption {
+	return func(o *subscribeOptions) { o.overflow = p }
+}
+
+type subscriber[T any] struct {
+	id      uint64
+	topic   string
+	handler func(T)
+	opts    subscribeOptions
+	ch      chan T
+
+	mu      sync.Mutex    // OverflowDropOldest 时串行化多个发布者
+	stop    chan struct{} // Unsubscribe 时关闭, 订阅者立即退出, 丢弃缓冲区中的事件
+	drain   chan struct{} // Close 时关闭, 订阅者处理完缓冲区中的事件后退出
+	done    chan struct{} // 订阅者协程退出时关闭
+	dropped atomic.Int64
+}
+
+func (s *subscriber[T]) loop() {
+	defer close(s.done)
+
+	for {
+		select {
+		case ev := <-s.ch:
+			s.handle(ev)
+		case <-s.stop:
+			return
+		case <-s.drain:
+			for {
+				select {
+				case ev := <-s.ch:
+					s.handle(ev)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle 通过 gosafe 执行 handler, panic 只影响当前事件
+func (s *subscriber[T]) handle(ev T) { _ = gosafe.Run(func() { s.handler(ev) }) }
+
+func (s *subscriber[T]) deliver(ctx context.Context, ev T) error {
+	switch s.opts.overflow {
+	case OverflowDropNewest:
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped.Inc()
+		}
+	case OverflowDropOldest:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for {
+			select {
+			case s.ch <- ev:
+				return nil
+			default:
+			}
+
+			select {
+			case <-s.ch:
+				s.dropped.Inc()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- ev:
+		case <-s.stop:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Subscription 表示一次订阅, 用于取消订阅和查询丢弃的事件数量
+type Subscription struct {
+	topic       string
+	unsubscribe func()
+	dropped     func() int64
+}
+
+// Topic 返回订阅的主题
+func (s *Subscription) Topic() string { return s.topic }
+
+// Unsubscribe 取消订阅, 缓冲区中尚未处理的事件会被丢弃, 不等待正在执行的 handler 返回, 可以在 handler 中调用. 多次调用只生效一次
+func (s *Subscription) Unsubscribe() { s.unsubscribe() }
+
+// Dropped 返回因缓冲区已满而被丢弃的事件数量
+func (s *Subscription) Dropped() int64 { return s.dropped() }
+
+// EventBus 是进程内按主题发布/订阅的事件总线, 每个订阅者有独立的缓冲区和协程, 慢订阅者不会影响其他订阅者
+// (OverflowBlock 时会阻塞发布者). handler 中的 panic 由 gosafe 捕获, 不会导致订阅者退出.
+// 必须通过 NewEventBus 创建, 可以并发使用.
+type EventBus[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[uint64]*subscriber[T]
+	nextID uint64
+	closed bool
+}
+
+// NewEventBus 创建一个事件总线
+func NewEventBus[T any]() *EventBus[T] {
+	return &EventBus[T]{topics: make(map[string]map[uint64]*subscriber[T])}
+}
+
+// Subscribe 订阅 topic, 之后发布到 topic 的事件会按发布顺序在订阅者自己的协程中依次传给 handler
+func (b *EventBus[T]) Subscribe(topic string, handler func(T), opts ...SubscribeOption) (*Subscription, error) {
+	o := subscribeOptions{buffer: 64}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.overflow == OverflowDropOldest {
+		// 没有缓冲区时无从丢弃最早的事件
+		o.buffer = max(o.buffer, 1)
+	}
+
+	s := &subscriber[T]{
+		topic: topic, handler: handler, opts: o, ch: make(chan T, o.buffer),
+		stop: make(chan struct{}), drain: make(chan struct{}), done: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrorBusClosed
+	}
+	b.nextID++
+	s.id = b.nextID
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[uint64]*subscriber[T])
+	}
+	b.topics[topic][s.id] = s
+	b.mu.Unlock()
+
+	gosafe.Go(s.loop)
+
+	var once sync.Once
+	return &Subscription{
+		topic:       topic,
+		unsubscribe: func() { once.Do(func() { b.remove(s) }) },
+		dropped:     s.dropped.Load,
+	}, nil
+}
+
+func (b *EventBus[T]) remove(s *subscriber[T]) {
+	b.mu.Lock()
+	if subs, ok := b.topics[s.topic]; ok {
+		if _, ok = subs[s.id]; ok {
+			delete(subs, s.id)
+			if len(subs) == 0 {
+				delete(b.topics, s.topic)
+			}
+			close(s.stop)
+		}
+	}
+	b.mu.Unlock()
+}
+
+// Publish 向 topic 的所有订阅者发布事件, 没有订阅者时直接返回; 总线关闭后返回 ErrorBusClosed
+func (b *EventBus[T]) Publish(topic string, event T) error {
+	return b.PublishCtx(context.Background(), topic, event)
+}
+
+// PublishCtx 与 Publish 相同, 但因 OverflowBlock 阻塞时可以通过 ctx 取消, 此时返回 ctx.Err(),
+// 已经投递给部分订阅者的事件不会撤回
+func (b *EventBus[T]) PublishCtx(ctx context.Context, topic string, event T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrorBusClosed
+	}
+	subs := make([]*subscriber[T], 0, len(b.topics[topic]))
+	for _, s := range b.topics[topic] {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if err := s.deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribers 返回 topic 当前的订阅者数量
+func (b *EventBus[T]) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.topics[topic])
+}
+
+// Close 关闭总线: 不再接受发布和订阅, 等待所有订阅者处理完缓冲区中的事件后返回. 重复调用无效, 不能在 handler 中调用
+func (b *EventBus[T]) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+
+	var subs []*subscriber[T]
+	for _, ts := range b.topics {
+		for _, s := range ts {
+			close(s.drain)
+			subs = append(subs, s)
+		}
+	}
+	b.topics = make(map[string]map[uint64]*subscriber[T])
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		<-s.done
+	}
+}
@@ -0,0 +1,105 @@
+package gosync
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Run("delivers in order per topic", func(t *testing.T) {
+		bus := NewEventBus[int]()
+
+		var mu sync.Mutex
+		var got []int
+		_, err := bus.Subscribe("a", func(v int) {
+			mu.Lock()
+			got = append(got, v)
+			mu.Unlock()
+		})
+		require.NoError(t, err)
+		_, err = bus.Subscribe("b", func(int) { t.Error("unexpected event on b") })
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, bus.Publish("a", i))
+		}
+		bus.Close()
+
+		assert.Len(t, got, 100)
+		for i := range got {
+			assert.Equal(t, i, got[i])
+		}
+		assert.Equal(t, ErrorBusClosed, bus.Publish("a", 1))
+	})
+
+	t.Run("overflow policies", func(t *testing.T) {
+		for _, tc := range []struct {
+			policy OverflowPolicy
+			want   []int
+		}{
+			{OverflowDropNewest, []int{0, 1, 2}},
+			{OverflowDropOldest, []int{0, 4, 5}},
+		} {
+			bus := NewEventBus[int]()
+			block := make(chan struct{})
+			started := make(chan struct{}, 1)
+
+			var got []int
+			sub, err := bus.Subscribe("t", func(v int) {
+				if v == 0 {
+					started <- struct{}{}
+					<-block
+				}
+				got = append(got, v)
+			}, WithBuffer(2), WithOverflow(tc.policy))
+			require.NoError(t, err)
+
+			require.NoError(t, bus.Publish("t", 0))
+			<-started
+			for i := 1; i <= 5; i++ {
+				require.NoError(t, bus.Publish("t", i))
+			}
+			close(block)
+			bus.Close()
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, int64(3), sub.Dropped())
+		}
+	})
+
+	t.Run("unsubscribe and panic isolation", func(t *testing.T) {
+		bus := NewEventBus[string]()
+
+		var mu sync.Mutex
+		var got []string
+		sub, err := bus.Subscribe("t", func(v string) {
+			if v == "panic" {
+				panic(v)
+			}
+			mu.Lock()
+			got = append(got, v)
+			mu.Unlock()
+		}, WithBuffer(0))
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Publish("t", "panic"))
+		require.NoError(t, bus.Publish("t", "ok"))
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(got) == 1
+		}, time.Second, time.Millisecond)
+
+		sub.Unsubscribe()
+		sub.Unsubscribe()
+		assert.Equal(t, 0, bus.Subscribers("t"))
+		require.NoError(t, bus.Publish("t", "ignored"))
+		bus.Close()
+
+		assert.Equal(t, []string{"ok"}, got)
+	})
+}
@@ -0,0 +1,166 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+var ErrorNoFutures = errors.New("no futures")
+
+// Future 表示一个异步计算的结果, 通过 Async 等函数创建, 完成后结果不再改变, 可以被多个协程同时等待
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+func newFuture[T any]() *Future[T] { return &Future[T]{done: make(chan struct{})} }
+
+func (f *Future[T]) complete(v T, err error) {
+	f.val, f.err = v, err
+	close(f.done)
+}
+
+// Async 通过 gosafe 在新协程中执行 fn, 返回代表其结果的 Future. fn panic 时结果的错误为 *gosafe.PanicError
+func Async[T any](fn func() (T, error)) *Future[T] {
+	f := newFuture[T]()
+
+	gosafe.Go(func() {
+		var v T
+		var err error
+		if perr := gosafe.Run(func() { v, err = fn() }); perr != nil {
+			var zero T
+			v, err = zero, perr
+		}
+		f.complete(v, err)
+	})
+
+	return f
+}
+
+// Resolved 返回一个已经完成的 Future, 结果为 (v, err)
+func Resolved[T any](v T, err error) *Future[T] {
+	f := newFuture[T]()
+	f.complete(v, err)
+
+	return f
+}
+
+// Done 返回在 Future 完成时关闭的 channel
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// Get 等待 Future 完成并返回其结果; ctx 先结束时返回 ctx.Err(), 不影响 Future 本身
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		// Future 可能在 ctx 结束的同时完成, 优先返回其结果
+		select {
+		case <-f.done:
+			return f.val, f.err
+		default:
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Then 在 f 成功完成后以其结果调用 fn, 返回代表 fn 结果的 Future; f 失败时不调用 fn, 直接传递错误
+func (f *Future[T]) Then(fn func(T) (T, error)) *Future[T] { return Then(f, fn) }
+
+// Map 与 Then 相同, 但 fn 不返回错误
+func (f *Future[T]) Map(fn func(T) T) *Future[T] {
+	return Then(f, func(v T) (T, error) { return fn(v), nil })
+}
+
+// Then 在 f 成功完成后以其结果调用 fn, 返回代表 fn 结果的 Future, 用于结果类型改变的链式调用.
+// f 失败时不调用 fn, 直接传递错误; fn panic 时结果的错误为 *gosafe.PanicError
+func Then[T, R any](f *Future[T], fn func(T) (R, error)) *Future[R] {
+	return Async(func() (R, error) {
+		<-f.done
+		if f.err != nil {
+			var zero R
+			return zero, f.err
+		}
+
+		return fn(f.val)
+	})
+}
+
+// settled 返回一个 channel, fs 中的 Future 每完成一个, 就发送一次其下标
+func settled[T any](fs []*Future[T]) <-chan int {
+	ch := make(chan int, len(fs))
+	for i, f := range fs {
+		gosafe.GoP(func(i int) {
+			<-f.done
+			ch <- i
+		}, i)
+	}
+
+	return ch
+}
+
+// All 返回一个 Future, 在 fs 全部成功后以按 fs 顺序排列的结果完成; 任何一个失败时立即以其错误完成, 不等待其他 Future
+func All[T any](fs ...*Future[T]) *Future[[]T] {
+	if len(fs) == 0 {
+		return Resolved([]T{}, nil)
+	}
+
+	return Async(func() ([]T, error) {
+		ch := settled(fs)
+		for range fs {
+			if f := fs[<-ch]; f.err != nil {
+				return nil, f.err
+			}
+		}
+
+		vals := make([]T, len(fs))
+		for i, f := range fs {
+			vals[i] = f.val
+		}
+
+		return vals, nil
+	})
+}
+
+// Any 返回一个 Future, 以 fs 中第一个成功的结果完成; 全部失败时以 errors.Join 合并后的所有错误(按 fs 顺序) 完成.
+// fs 为空时错误为 ErrorNoFutures
+func Any[T any](fs ...*Future[T]) *Future[T] {
+	if len(fs) == 0 {
+		var zero T
+		return Resolved(zero, ErrorNoFutures)
+	}
+
+	return Async(func() (T, error) {
+		ch := settled(fs)
+		for range fs {
+			if f := fs[<-ch]; f.err == nil {
+				return f.val, nil
+			}
+		}
+
+		errs := make([]error, len(fs))
+		for i, f := range fs {
+			errs[i] = f.err
+		}
+
+		var zero T
+		return zero, errors.Join(errs...)
+	})
+}
+
+// Race 返回一个 Future, 以 fs 中第一个完成的结果完成, 无论成功还是失败. fs 为空时错误为 ErrorNoFutures
+func Race[T any](fs ...*Future[T]) *Future[T] {
+	if len(fs) == 0 {
+		var zero T
+		return Resolved(zero, ErrorNoFutures)
+	}
+
+	return Async(func() (T, error) {
+		f := fs[<-settled(fs)]
+		return f.val, f.err
+	})
+}
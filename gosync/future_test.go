@@ -0,0 +1,97 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func delayed[T any](d time.Duration, v T, err error) *Future[T] {
+	return Async(func() (T, error) {
+		time.Sleep(d)
+		return v, err
+	})
+}
+
+func TestFuture(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	f := delayed(20*time.Millisecond, 21, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := f.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	<-f.Done()
+	v, err := f.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 21, v)
+
+	s, err := Then(f.Map(func(v int) int { return v * 2 }), func(v int) (string, error) {
+		return strconv.Itoa(v), nil
+	}).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "42", s)
+
+	errBoom := errors.New("boom")
+	called := false
+	_, err = Resolved(0, errBoom).Then(func(v int) (int, error) { called = true; return v, nil }).Get(context.Background())
+	assert.ErrorIs(t, err, errBoom)
+	assert.False(t, called)
+
+	var perr *gosafe.PanicError
+	_, err = Async(func() (int, error) { panic("async") }).Get(context.Background())
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "async", perr.Value)
+
+	_, err = f.Map(func(int) int { panic("map") }).Get(context.Background())
+	assert.ErrorAs(t, err, &perr)
+}
+
+func TestFutureCombinators(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	ctx := context.Background()
+	errA, errB := errors.New("a"), errors.New("b")
+
+	vals, err := All(delayed(10*time.Millisecond, 1, nil), delayed(0, 2, nil), Resolved(3, nil)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, vals)
+
+	start := time.Now()
+	_, err = All(delayed(200*time.Millisecond, 1, nil), delayed(0, 0, errA)).Get(ctx)
+	assert.ErrorIs(t, err, errA)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	vals, err = All[int]().Get(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, vals)
+
+	v, err := Any(delayed(0, 0, errA), delayed(10*time.Millisecond, 2, nil), delayed(200*time.Millisecond, 3, nil)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	_, err = Any(delayed(0, 0, errA), Resolved(0, errB)).Get(ctx)
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+
+	_, err = Any[int]().Get(ctx)
+	assert.Equal(t, ErrorNoFutures, err)
+
+	_, err = Race(delayed(200*time.Millisecond, 1, nil), delayed(0, 0, errB)).Get(ctx)
+	assert.ErrorIs(t, err, errB)
+
+	var perr *gosafe.PanicError
+	_, err = Race(Async(func() (int, error) { panic("race") }), delayed(200*time.Millisecond, 1, nil)).Get(ctx)
+	assert.ErrorAs(t, err, &perr)
+
+	_, err = Race[int]().Get(ctx)
+	assert.Equal(t, ErrorNoFutures, err)
+}
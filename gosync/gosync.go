@@ -1,6 +1,7 @@
 package gosync
 
 import (
+	"context"
 	"github.com/puresnr/go/gosafe"
 	"sync"
 	"time"
@@ -23,6 +24,84 @@ func GoWait(funcs ...func()) {
 	wg.Wait()
 }
 
+// GoWaitCtx 用于并发的执行一组函数, 并等待所有函数执行完毕或 ctx 结束.
+//
+// 参数：
+//
+//	ctx：控制等待的上下文, 结束时停止等待。
+//	funcs：需要执行的函数列表, 入参为 ctx 派生出的上下文(携带 ctx 的值), 停止等待时该上下文会被取消, 函数应当据此尽快返回。
+//
+// 返回值：
+//
+//	停止等待时仍未执行完毕的函数在 funcs 中的下标, 按升序排列; 所有函数都执行完毕时返回 nil。
+//	ctx 在调用时已经结束时不执行任何函数, 返回所有下标。
+//
+// 说明：
+//
+//	每个函数结束时向一个容量为 len(funcs) 的通道发送自己的下标, 不会阻塞, 因此停止等待后不会遗留任何等待协程,
+//	未执行完毕的函数在收到取消信号并返回后, 其协程也会随之退出。
+func GoWaitCtx(ctx context.Context, funcs ...func(context.Context)) []int {
+	if ctx.Err() != nil {
+		return unfinished(make([]bool, len(funcs)), nil)
+	}
+
+	// funcs 使用的上下文不直接继承 ctx 的取消信号, 而是在统计完未完成的函数之后再取消,
+	// 避免函数响应取消后返回得太快, 被误判为已经执行完毕
+	fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	dc := make(chan int, len(funcs))
+	for i, f := range funcs {
+		gosafe.GoP(func(idx int) {
+			defer func() { dc <- idx }()
+
+			f(fctx)
+		}, i)
+	}
+
+	finished := make([]bool, len(funcs))
+	for left := len(funcs); left != 0; left-- {
+		select {
+		case idx := <-dc:
+			finished[idx] = true
+		case <-ctx.Done():
+			return unfinished(finished, dc)
+		}
+	}
+
+	return nil
+}
+
+// unfinished 收集 dc 中已经到达的完成信号后, 返回仍未完成的函数下标
+func unfinished(finished []bool, dc <-chan int) []int {
+drain:
+	for {
+		select {
+		case idx := <-dc:
+			finished[idx] = true
+		default:
+			break drain
+		}
+	}
+
+	var idxs []int
+	for i := range finished {
+		if !finished[i] {
+			idxs = append(idxs, i)
+		}
+	}
+
+	return idxs
+}
+
+// GoWaitTimeout 与 GoWaitCtx 相同, 等待时间上限为 timeout, 超时后取消传给 funcs 的上下文并返回未执行完毕的函数下标
+func GoWaitTimeout(timeout time.Duration, funcs ...func(context.Context)) []int {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return GoWaitCtx(ctx, funcs...)
+}
+
 // GoWaitWithTimeout 函数用于等待一组函数执行完成或超时。
 //
 // 参数：
@@ -34,20 +113,12 @@ func GoWait(funcs ...func()) {
 //
 //	如果等待超时则返回 true，否则返回 false。
 //
-// 说明：
-//
-//	函数通过 select 语句同时监听超时事件和函数执行完成事件。
-//	如果超时事件发生，则返回 true；如果所有函数执行完成事件先发生，则返回 false。
+// Deprecated: funcs 无法感知超时, 超时后仍会继续执行. 请使用 GoWaitTimeout 或 GoWaitCtx.
 func GoWaitWithTimeout(timeout uint, funcs ...func()) bool {
-	select {
-	case <-time.After(time.Duration(timeout) * time.Second):
-		return true
-	case <-func() <-chan struct{} {
-		dc := make(chan struct{})
-		go func() { GoWait(funcs...); close(dc) }()
-		return dc
-	}():
+	cfuncs := make([]func(context.Context), len(funcs))
+	for i, f := range funcs {
+		cfuncs[i] = func(context.Context) { f() }
 	}
 
-	return false
+	return len(GoWaitTimeout(time.Duration(timeout)*time.Second, cfuncs...)) != 0
 }
@@ -0,0 +1,52 @@
+package gosync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestGoWaitTimeout(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	t.Run("all finished", func(t *testing.T) {
+		assert.Nil(t, GoWaitTimeout(time.Second,
+			func(context.Context) {},
+			func(context.Context) { time.Sleep(5 * time.Millisecond) },
+		))
+	})
+
+	t.Run("timeout cancels and reports unfinished", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		idxs := GoWaitTimeout(20*time.Millisecond,
+			func(context.Context) {},
+			func(ctx context.Context) { <-ctx.Done(); close(cancelled) },
+			func(ctx context.Context) { <-ctx.Done() },
+		)
+		assert.Equal(t, []int{1, 2}, idxs)
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("task was not cancelled")
+		}
+	})
+
+	t.Run("parent ctx", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var ran atomic.Bool
+		assert.Equal(t, []int{0, 1}, GoWaitCtx(ctx, func(ctx context.Context) { <-ctx.Done() }, func(context.Context) { ran.Store(true) }))
+		time.Sleep(10 * time.Millisecond)
+		assert.False(t, ran.Load(), "ctx 已经结束时不启动任何函数")
+	})
+
+	t.Run("deprecated wrapper", func(t *testing.T) {
+		assert.False(t, GoWaitWithTimeout(1, func() {}))
+		assert.True(t, GoWaitWithTimeout(0, func() { time.Sleep(10 * time.Millisecond) }))
+	})
+}
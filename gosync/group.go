@@ -0,0 +1,108 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+// Group 用于并发执行一组返回 error 的函数并等待它们结束, 类似 errgroup.
+// 函数中的 panic 会被 gosafe 捕获, 并以 *gosafe.PanicError 的形式作为该函数的错误返回.
+// 必须通过 NewGroup 或 NewGroupJoin 创建, Wait 之后不能再调用 Go.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	join   bool
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup 创建一个 Group, 返回的 ctx 会在第一个函数出错(或 panic) 时被取消, 或在 Wait 返回时被取消.
+// Wait 只返回第一个错误.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	g := &Group{}
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+
+	return g, g.ctx
+}
+
+// NewGroupJoin 创建一个 Group, 与 NewGroup 不同的是函数出错时不会取消 ctx, Wait 返回所有错误通过 errors.Join 合并后的结果.
+// 返回的 ctx 仍会在 Wait 返回时被取消.
+func NewGroupJoin(ctx context.Context) (*Group, context.Context) {
+	g, gctx := NewGroup(ctx)
+	g.join = true
+
+	return g, gctx
+}
+
+// Go 通过 gosafe 在新的协程中执行 f, 入参为 Group 的 ctx
+func (g *Group) Go(f func(context.Context) error) {
+	g.wg.Add(1)
+
+	gosafe.GoP(func(ef func(context.Context) error) {
+		defer g.wg.Done()
+
+		var err error
+		if perr := gosafe.Run(func() { err = ef(g.ctx) }); perr != nil {
+			err = perr
+		}
+
+		if err != nil {
+			g.fail(err)
+		}
+	}, f)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	g.errs = append(g.errs, err)
+	first := len(g.errs) == 1
+	g.mu.Unlock()
+
+	if first && !g.join {
+		g.cancel(err)
+	}
+}
+
+// Wait 等待所有函数执行完毕, 返回第一个错误(NewGroup) 或合并后的全部错误(NewGroupJoin), 全部成功时返回 nil
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if g.join {
+		return errors.Join(g.errs...)
+	}
+
+	return g.errs[0]
+}
+
+// GoWaitE 与 GoWait 类似, 但函数可以返回错误: 并发执行 funcs 并等待全部结束, 任一函数出错或 panic 时取消传给其他函数的 ctx,
+// 返回第一个错误
+func GoWaitE(ctx context.Context, funcs ...func(context.Context) error) error {
+	g, _ := NewGroup(ctx)
+	for _, f := range funcs {
+		g.Go(f)
+	}
+
+	return g.Wait()
+}
+
+// GoWaitJoin 并发执行 funcs 并等待全部结束, 函数出错时不会取消其他函数, 返回所有错误通过 errors.Join 合并后的结果
+func GoWaitJoin(ctx context.Context, funcs ...func(context.Context) error) error {
+	g, _ := NewGroupJoin(ctx)
+	for _, f := range funcs {
+		g.Go(f)
+	}
+
+	return g.Wait()
+}
@@ -0,0 +1,66 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	e1, e2 := errors.New("e1"), errors.New("e2")
+
+	t.Run("all succeed", func(t *testing.T) {
+		assert.NoError(t, GoWaitE(context.Background(),
+			func(context.Context) error { return nil },
+			func(context.Context) error { return nil },
+		))
+	})
+
+	t.Run("first error cancels others", func(t *testing.T) {
+		err := GoWaitE(context.Background(),
+			func(context.Context) error { return e1 },
+			func(ctx context.Context) error {
+				select {
+				case <-ctx.Done():
+					assert.ErrorIs(t, context.Cause(ctx), e1)
+					return ctx.Err()
+				case <-time.After(time.Second):
+					return e2
+				}
+			},
+		)
+		assert.Equal(t, e1, err)
+	})
+
+	t.Run("join keeps all errors", func(t *testing.T) {
+		err := GoWaitJoin(context.Background(),
+			func(context.Context) error { return e1 },
+			func(ctx context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				assert.NoError(t, ctx.Err())
+				return e2
+			},
+		)
+		assert.ErrorIs(t, err, e1)
+		assert.ErrorIs(t, err, e2)
+	})
+
+	t.Run("panic as error", func(t *testing.T) {
+		err := GoWaitE(context.Background(), func(context.Context) error { panic(e1) })
+
+		var pe *gosafe.PanicError
+		assert.ErrorAs(t, err, &pe)
+		assert.ErrorIs(t, err, e1)
+	})
+
+	t.Run("ctx cancelled after wait", func(t *testing.T) {
+		g, ctx := NewGroup(context.Background())
+		g.Go(func(context.Context) error { return nil })
+		assert.NoError(t, g.Wait())
+		assert.Error(t, ctx.Err())
+	})
+}
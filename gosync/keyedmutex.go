@@ -0,0 +1,188 @@
+package gosync
+
+import (
+	"context"
+	"sync"
+)
+
+type keyedLock struct {
+	refs           int // 持有或等待该 key 的数量, 为 0 时从 map 中删除
+	readers        int
+	writer         bool
+	writersWaiting int
+	changed        chan struct{} // 状态变化时关闭并替换, 用于唤醒所有等待者
+}
+
+func (l *keyedLock) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *keyedLock) can(write bool) bool {
+	if write {
+		return !l.writer && l.readers == 0
+	}
+
+	// 有写者在等待时不再让新的读者进入, 避免写者饥饿
+	return !l.writer && l.writersWaiting == 0
+}
+
+func (l *keyedLock) take(write bool) {
+	if write {
+		l.writer = true
+	} else {
+		l.readers++
+	}
+}
+
+// KeyedRWMutex 是按 key 加锁的读写锁, 不同 key 之间互不影响.
+// 每个 key 的锁在第一次使用时创建, 没有任何持有者和等待者时立即释放, 因此不会随 key 的数量无限增长.
+// 零值可以直接使用, 使用后不能复制.
+type KeyedRWMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+// ref 获取 key 对应的锁并增加引用计数, 调用方需持有 mu
+func (k *KeyedRWMutex[K]) ref(key K) *keyedLock {
+	if k.locks == nil {
+		k.locks = make(map[K]*keyedLock)
+	}
+
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{changed: make(chan struct{})}
+		k.locks[key] = l
+	}
+	l.refs++
+
+	return l
+}
+
+// unref 减少引用计数, 为 0 时删除, 调用方需持有 mu
+func (k *KeyedRWMutex[K]) unref(key K, l *keyedLock) {
+	if l.refs--; l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
+
+func (k *KeyedRWMutex[K]) tryLock(key K, write bool) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	l := k.ref(key)
+	if !l.can(write) {
+		k.unref(key, l)
+		return false
+	}
+	l.take(write)
+
+	return true
+}
+
+func (k *KeyedRWMutex[K]) lock(ctx context.Context, key K, write bool) error {
+	k.mu.Lock()
+
+	l := k.ref(key)
+	if write {
+		l.writersWaiting++
+	}
+
+	for !l.can(write) {
+		changed := l.changed
+		k.mu.Unlock()
+
+		select {
+		case <-changed:
+			k.mu.Lock()
+		case <-ctx.Done():
+			k.mu.Lock()
+			if write {
+				l.writersWaiting--
+				l.notify()
+			}
+			k.unref(key, l)
+			k.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+
+	if write {
+		l.writersWaiting--
+	}
+	l.take(write)
+	k.mu.Unlock()
+
+	return nil
+}
+
+func (k *KeyedRWMutex[K]) unlock(key K, write bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	l, ok := k.locks[key]
+	if !ok || (write && !l.writer) || (!write && l.readers == 0) {
+		panic("gosync: unlock of unlocked key")
+	}
+
+	if write {
+		l.writer = false
+	} else {
+		l.readers--
+	}
+	l.notify()
+	k.unref(key, l)
+}
+
+// Lock 对 key 加写锁, 阻塞直到成功
+func (k *KeyedRWMutex[K]) Lock(key K) { _ = k.lock(context.Background(), key, true) }
+
+// LockCtx 对 key 加写锁, 阻塞直到成功或 ctx 结束, ctx 结束时返回 ctx.Err() 且不持有锁
+func (k *KeyedRWMutex[K]) LockCtx(ctx context.Context, key K) error { return k.lock(ctx, key, true) }
+
+// TryLock 尝试对 key 加写锁, 不阻塞, 返回是否成功
+func (k *KeyedRWMutex[K]) TryLock(key K) bool { return k.tryLock(key, true) }
+
+// Unlock 释放 key 的写锁, key 未加写锁时 panic
+func (k *KeyedRWMutex[K]) Unlock(key K) { k.unlock(key, true) }
+
+// RLock 对 key 加读锁, 阻塞直到成功
+func (k *KeyedRWMutex[K]) RLock(key K) { _ = k.lock(context.Background(), key, false) }
+
+// RLockCtx 对 key 加读锁, 阻塞直到成功或 ctx 结束, ctx 结束时返回 ctx.Err() 且不持有锁
+func (k *KeyedRWMutex[K]) RLockCtx(ctx context.Context, key K) error { return k.lock(ctx, key, false) }
+
+// TryRLock 尝试对 key 加读锁, 不阻塞, 返回是否成功
+func (k *KeyedRWMutex[K]) TryRLock(key K) bool { return k.tryLock(key, false) }
+
+// RUnlock 释放 key 的一个读锁, key 未加读锁时 panic
+func (k *KeyedRWMutex[K]) RUnlock(key K) { k.unlock(key, false) }
+
+// Len 返回当前被持有或等待中的 key 数量
+func (k *KeyedRWMutex[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.locks)
+}
+
+// KeyedMutex 是按 key 加锁的互斥锁, 行为与只使用写锁的 KeyedRWMutex 相同.
+// 零值可以直接使用, 使用后不能复制.
+type KeyedMutex[K comparable] struct {
+	rw KeyedRWMutex[K]
+}
+
+// Lock 对 key 加锁, 阻塞直到成功
+func (k *KeyedMutex[K]) Lock(key K) { k.rw.Lock(key) }
+
+// LockCtx 对 key 加锁, 阻塞直到成功或 ctx 结束, ctx 结束时返回 ctx.Err() 且不持有锁
+func (k *KeyedMutex[K]) LockCtx(ctx context.Context, key K) error { return k.rw.LockCtx(ctx, key) }
+
+// TryLock 尝试对 key 加锁, 不阻塞, 返回是否成功
+func (k *KeyedMutex[K]) TryLock(key K) bool { return k.rw.TryLock(key) }
+
+// Unlock 释放 key 的锁, key 未加锁时 panic
+func (k *KeyedMutex[K]) Unlock(key K) { k.rw.Unlock(key) }
+
+// Len 返回当前被持有或等待中的 key 数量
+func (k *KeyedMutex[K]) Len() int { return k.rw.Len() }
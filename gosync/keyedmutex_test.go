@@ -0,0 +1,83 @@
+package gosync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	t.Run("serialises per key and frees entries", func(t *testing.T) {
+		var km KeyedMutex[int]
+		counts := make([]int, 4)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 400; i++ {
+			wg.Add(1)
+			go func(key int) {
+				defer wg.Done()
+				km.Lock(key)
+				counts[key]++
+				km.Unlock(key)
+			}(i % 4)
+		}
+		wg.Wait()
+
+		assert.Equal(t, []int{100, 100, 100, 100}, counts)
+		assert.Equal(t, 0, km.Len())
+	})
+
+	t.Run("try lock and ctx", func(t *testing.T) {
+		var km KeyedMutex[string]
+		require.True(t, km.TryLock("a"))
+		assert.False(t, km.TryLock("a"))
+		assert.True(t, km.TryLock("b"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, km.LockCtx(ctx, "a"), context.DeadlineExceeded)
+
+		km.Unlock("a")
+		km.Unlock("b")
+		assert.Equal(t, 0, km.Len())
+		assert.Panics(t, func() { km.Unlock("a") })
+	})
+}
+
+func TestKeyedRWMutex(t *testing.T) {
+	var km KeyedRWMutex[string]
+
+	km.RLock("a")
+	assert.True(t, km.TryRLock("a"))
+	assert.False(t, km.TryLock("a"))
+
+	locked := make(chan struct{})
+	go func() {
+		km.Lock("a")
+		close(locked)
+	}()
+
+	// a waiting writer blocks new readers
+	assert.Eventually(t, func() bool {
+		if km.TryRLock("a") {
+			km.RUnlock("a")
+			return false
+		}
+		return true
+	}, time.Second, time.Millisecond)
+
+	km.RUnlock("a")
+	km.RUnlock("a")
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, km.RLockCtx(ctx, "a"), context.DeadlineExceeded)
+
+	km.Unlock("a")
+	assert.Equal(t, 0, km.Len())
+}
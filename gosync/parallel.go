@@ -0,0 +1,75 @@
+package gosync
+
+import (
+	"context"
+	"sync"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+// ParallelMap 对 items 中的每个元素并发执行 f, 同时执行的数量不超过 limit, 结果按 items 的顺序返回.
+//
+// 参数：
+//
+//	ctx：传给 f 的上下文; ctx 结束后尚未开始的元素不再执行, 其错误为 ctx.Err()。
+//	items：待处理的元素。
+//	limit：最大并发数量, 小于 1 时不限制。
+//	f：处理函数, panic 会被 gosafe 捕获并以 *gosafe.PanicError 的形式作为该元素的错误。
+//
+// 返回值：
+//
+//	results 与 errs 的长度都与 items 相同, 下标一一对应; 某个元素出错时 results 中对应位置为零值。
+//	任何一个元素出错都不会影响其他元素的执行。
+func ParallelMap[T, R any](ctx context.Context, items []T, limit int, f func(context.Context, T) (R, error)) (results []R, errs []error) {
+	results, errs = make([]R, len(items)), make([]error, len(items))
+	if len(items) == 0 {
+		return
+	}
+
+	if limit < 1 || limit > len(items) {
+		limit = len(items)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+
+	for i := range items {
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(items); j++ {
+				errs[j] = err
+			}
+			wg.Wait()
+			return
+		}
+
+		wg.Add(1)
+		gosafe.GoP(func(idx int) {
+			defer func() { <-sem; wg.Done() }()
+
+			if perr := gosafe.Run(func() { results[idx], errs[idx] = f(ctx, items[idx]) }); perr != nil {
+				var zero R
+				results[idx], errs[idx] = zero, perr
+			}
+		}, i)
+	}
+
+	wg.Wait()
+
+	return
+}
+
+// ParallelForEach 与 ParallelMap 相同, 用于不需要返回值的场景, 返回的 errs 与 items 下标一一对应
+func ParallelForEach[T any](ctx context.Context, items []T, limit int, f func(context.Context, T) error) []error {
+	_, errs := ParallelMap(ctx, items, limit, func(ctx context.Context, t T) (struct{}, error) {
+		return struct{}{}, f(ctx, t)
+	})
+
+	return errs
+}
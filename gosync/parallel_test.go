@@ -0,0 +1,59 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestParallelMap(t *testing.T) {
+	t.Run("ordered results and limit", func(t *testing.T) {
+		items := []int{5, 4, 3, 2, 1, 0}
+		var cur, peak atomic.Int64
+		results, errs := ParallelMap(context.Background(), items, 2, func(_ context.Context, i int) (string, error) {
+			n := cur.Inc()
+			defer cur.Dec()
+			for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+			}
+			time.Sleep(time.Duration(i) * time.Millisecond)
+			return strconv.Itoa(i), nil
+		})
+
+		assert.Equal(t, []string{"5", "4", "3", "2", "1", "0"}, results)
+		assert.Equal(t, make([]error, len(items)), errs)
+		assert.LessOrEqual(t, peak.Load(), int64(2))
+	})
+
+	t.Run("per item errors and panics", func(t *testing.T) {
+		e := errors.New("odd")
+		results, errs := ParallelMap(context.Background(), []int{0, 1, 2}, 0, func(_ context.Context, i int) (int, error) {
+			if i == 2 {
+				panic("boom")
+			}
+			if i%2 == 1 {
+				return i, e
+			}
+			return i * 10, nil
+		})
+
+		assert.Equal(t, []int{0, 1, 0}, results)
+		assert.NoError(t, errs[0])
+		assert.Equal(t, e, errs[1])
+		var pe *gosafe.PanicError
+		assert.ErrorAs(t, errs[2], &pe)
+	})
+
+	t.Run("cancelled ctx skips items", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		errs := ParallelForEach(ctx, []int{1, 2}, 1, func(context.Context, int) error { return nil })
+		assert.Equal(t, []error{context.Canceled, context.Canceled}, errs)
+	})
+}
@@ -0,0 +1,263 @@
+package gosync
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+// 以下是基于 channel 的流水线组合函数, 约定:
+//   - 每个阶段在自己的协程(通过 gosafe 启动) 中运行, 输入关闭或 ctx 结束后关闭输出并退出
+//   - ctx 结束后不再向输出发送数据, 因此下游不读取时也不会导致上游协程泄漏
+//   - 用户函数中的 panic 由 gosafe 捕获并打印, 对应的元素被丢弃, 流水线继续运行
+
+// send 向 out 发送 v, ctx 结束时返回 false
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv 从 in 读取一个元素, in 关闭或 ctx 结束时返回 false
+func recv[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Generate 不断调用 f 生成元素并发送到返回的 channel, f 返回 false 时结束; f panic 时同样结束
+func Generate[T any](ctx context.Context, f func(context.Context) (T, bool)) <-chan T {
+	out := make(chan T)
+
+	gosafe.Go(func() {
+		defer close(out)
+
+		for ctx.Err() == nil {
+			var v T
+			var ok bool
+			if gosafe.Run(func() { v, ok = f(ctx) }) != nil || !ok {
+				return
+			}
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Map 对 in 中的每个元素调用 f, 按顺序输出结果. 需要并发时可以对同一个 in 调用多次 Map 再通过 Merge 合并(此时不再保序)
+func Map[T, R any](ctx context.Context, in <-chan T, f func(context.Context, T) R) <-chan R {
+	out := make(chan R)
+
+	gosafe.Go(func() {
+		defer close(out)
+
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+
+			var r R
+			if gosafe.Run(func() { r = f(ctx, v) }) != nil {
+				continue
+			}
+			if !send(ctx, out, r) {
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Filter 只输出 in 中 keep 返回 true 的元素
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+
+	gosafe.Go(func() {
+		defer close(out)
+
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+
+			var k bool
+			if gosafe.Run(func() { k = keep(v) }) != nil || !k {
+				continue
+			}
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Batch 把 in 中的元素按 size 个一组输出; interval 大于 0 时, 一组中的第一个元素等待超过 interval 后, 即使不足 size 个也会输出.
+// in 关闭时输出剩余的元素, ctx 结束时丢弃剩余的元素. size 小于 1 时按 1 处理.
+func Batch[T any](ctx context.Context, in <-chan T, size int, interval time.Duration) <-chan []T {
+	size = max(size, 1)
+	out := make(chan []T)
+
+	gosafe.Go(func() {
+		defer close(out)
+
+		var batch []T
+		var timer *time.Timer
+		var expire <-chan time.Time
+
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, expire = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+
+				batch = append(batch, v)
+				if len(batch) == 1 && interval > 0 {
+					timer = time.NewTimer(interval)
+					expire = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-expire:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Merge 把多个 channel 合并为一个, 所有输入都关闭后关闭输出, 不保证顺序
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		gosafe.GoP(func(in <-chan T) {
+			defer wg.Done()
+
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}, in)
+	}
+
+	gosafe.Go(func() {
+		wg.Wait()
+		close(out)
+	})
+
+	return out
+}
+
+// Tee 把 in 中的每个元素复制到 n 个输出中, 每个元素都发送给所有输出之后才读取下一个, 因此速度取决于最慢的消费者
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	routs := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		routs[i] = outs[i]
+	}
+
+	gosafe.Go(func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+
+			if !sendAll(ctx, outs, v) {
+				return
+			}
+		}
+	})
+
+	return routs
+}
+
+// sendAll 同时向所有 outs 发送 v, 哪个输出先准备好就先发送给哪个, 避免消费者的读取顺序导致死锁. ctx 结束时返回 false
+func sendAll[T any](ctx context.Context, outs []chan T, v T) bool {
+	cases := make([]reflect.SelectCase, len(outs)+1)
+	cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		// v 为 nil 接口时, reflect.ValueOf 无法表示, 使用 T 的零值
+		rv = reflect.Zero(reflect.TypeOf((*T)(nil)).Elem())
+	}
+	for i, out := range outs {
+		cases[i+1] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(out), Send: rv}
+	}
+
+	for left := len(outs); left != 0; left-- {
+		chosen, _, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return false
+		}
+		// 已经发送成功的输出置为 nil channel, 之后不会再被选中
+		cases[chosen].Chan = reflect.Value{}
+	}
+
+	return true
+}
+
+// Drain 读取并丢弃 in 中的所有元素, 直到 in 关闭或 ctx 结束, 返回读取的数量. 用于提前结束消费时让上游协程正常退出
+func Drain[T any](ctx context.Context, in <-chan T) int {
+	n := 0
+	for {
+		if _, ok := recv(ctx, in); !ok {
+			return n
+		}
+		n++
+	}
+}
@@ -0,0 +1,82 @@
+package gosync
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+)
+
+func count(n int) func(context.Context) (int, bool) {
+	i := 0
+	return func(context.Context) (int, bool) {
+		i++
+		return i, i <= n
+	}
+}
+
+func collect[T any](in <-chan T) []T {
+	var out []T
+	for v := range in {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestPipeline(t *testing.T) {
+	gosafe.TrackTest(t)
+	ctx := context.Background()
+
+	t.Run("generate map filter", func(t *testing.T) {
+		sq := Map(ctx, Generate(ctx, count(6)), func(_ context.Context, v int) int {
+			if v == 3 {
+				panic("skip me")
+			}
+			return v * v
+		})
+		even := Filter(ctx, sq, func(v int) bool { return v%2 == 0 })
+		assert.Equal(t, []int{4, 16, 36}, collect(even))
+	})
+
+	t.Run("batch by size and time", func(t *testing.T) {
+		assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, collect(Batch(ctx, Generate(ctx, count(5)), 2, 0)))
+
+		in := make(chan int)
+		out := Batch(ctx, in, 10, 10*time.Millisecond)
+		in <- 1
+		in <- 2
+		assert.Equal(t, []int{1, 2}, <-out)
+		close(in)
+		_, ok := <-out
+		assert.False(t, ok)
+	})
+
+	t.Run("merge and tee", func(t *testing.T) {
+		merged := collect(Merge(ctx, Generate(ctx, count(3)), Generate(ctx, count(2))))
+		sort.Ints(merged)
+		assert.Equal(t, []int{1, 1, 2, 2, 3}, merged)
+
+		outs := Tee(ctx, Generate(ctx, count(3)), 2)
+		// reading the second output first must not deadlock
+		var second []int
+		for i := 0; i < 3; i++ {
+			second = append(second, <-outs[1])
+			<-outs[0]
+		}
+		assert.Equal(t, []int{1, 2, 3}, second)
+		assert.Empty(t, collect(outs[0]))
+		assert.Empty(t, collect(outs[1]))
+	})
+
+	t.Run("cancel stops every stage", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		infinite := Generate(cctx, func(context.Context) (int, bool) { return 1, true })
+		out := Batch(cctx, Map(cctx, infinite, func(_ context.Context, v int) int { return v }), 3, 0)
+		<-out
+		cancel()
+		Drain(ctx, out)
+	})
+}
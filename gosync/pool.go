@@ -0,0 +1,300 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"go.uber.org/atomic"
+)
+
+var (
+	ErrorPoolClosed = errors.New("pool closed")
+	ErrorPoolFull   = errors.New("pool queue full")
+)
+
+// SubmitMode 决定任务队列已满时 Submit 的行为
+type SubmitMode int
+
+const (
+	SubmitBlock    SubmitMode = iota // 阻塞直到队列有空位
+	SubmitNonBlock                   // 立即返回 ErrorPoolFull
+	SubmitDrop                       // 直接丢弃任务并返回 nil, 丢弃数量可以通过 Dropped 查询
+)
+
+type poolOptions struct {
+	minWorkers   int
+	queueSize    int
+	idleTimeout  time.Duration
+	mode         SubmitMode
+	panicHandler func(*gosafe.PanicError)
+}
+
+// PoolOption 用于配置 Pool
+type PoolOption func(*poolOptions)
+
+// WithMinWorkers 设置常驻 worker 数量, 小于最大 worker 数量时 Pool 为弹性模式:
+// 任务积压时按需增加 worker, 多出的 worker 空闲超过 idleTimeout 后退出
+func WithMinWorkers(n int) PoolOption { return func(o *poolOptions) { o.minWorkers = n } }
+
+// WithQueueSize 设置任务队列长度, 默认为 0, 即没有空闲 worker 时任务无法入队
+func WithQueueSize(n int) PoolOption { return func(o *poolOptions) { o.queueSize = n } }
+
+// WithIdleTimeout 设置弹性模式下非常驻 worker 的空闲超时时间, 默认 1 分钟
+func WithIdleTimeout(d time.Duration) PoolOption { return func(o *poolOptions) { o.idleTimeout = d } }
+
+// WithSubmitMode 设置队列已满时 Submit 的行为, 默认 SubmitBlock
+func WithSubmitMode(m SubmitMode) PoolOption { return func(o *poolOptions) { o.mode = m } }
+
+// WithPanicHandler 设置任务 panic 时的回调, panic 总是只影响当前任务, 不会导致 worker 退出
+func WithPanicHandler(h func(*gosafe.PanicError)) PoolOption {
+	return func(o *poolOptions) { o.panicHandler = h }
+}
+
+// Pool 是一个并发数量有上限的协程池, 所有 worker 都通过 gosafe 启动.
+// 任务的入参 ctx 会在 ShutdownNow 或 Shutdown 超时时被取消, 长时间运行的任务应当监听它.
+// 必须通过 NewPool 创建.
+type Pool struct {
+	opts       poolOptions
+	maxWorkers int
+	tasks      chan func(context.Context)
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	discard    atomic.Bool
+
+	closeMu    sync.RWMutex
+	closed     bool
+	closing    chan struct{}  // 关闭时关闭, 唤醒阻塞在 SubmitBlock 中的提交者
+	submitting sync.WaitGroup // 正在提交的任务, 全部返回后才能关闭 tasks
+
+	workerMu sync.Mutex
+	workers  int
+
+	running atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewPool 创建一个最多有 maxWorkers 个 worker 的协程池, maxWorkers 小于 1 时按 1 处理.
+// 默认为固定模式, 即立即启动 maxWorkers 个常驻 worker; 通过 WithMinWorkers 可以切换为弹性模式.
+func NewPool(maxWorkers int, opts ...PoolOption) *Pool {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+
+	o := poolOptions{minWorkers: maxWorkers, idleTimeout: time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.minWorkers = min(max(o.minWorkers, 0), maxWorkers)
+	o.queueSize = max(o.queueSize, 0)
+
+	p := &Pool{opts: o, maxWorkers: maxWorkers, tasks: make(chan func(context.Context), o.queueSize), closing: make(chan struct{})}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.workerMu.Lock()
+	for i := 0; i < o.minWorkers; i++ {
+		p.spawn(false, nil)
+	}
+	p.workerMu.Unlock()
+
+	return p
+}
+
+// spawn 启动一个 worker, first 不为 nil 时作为 worker 的第一个任务执行, 调用方需持有 workerMu
+func (p *Pool) spawn(elastic bool, first func(context.Context)) {
+	p.workers++
+	p.wg.Add(1)
+	gosafe.Go(func() { p.work(elastic, first) })
+}
+
+// grow 在还没有达到 worker 上限时增加一个弹性 worker 并返回 true, first 交给新 worker 直接执行
+func (p *Pool) grow(first func(context.Context)) bool {
+	p.workerMu.Lock()
+	defer p.workerMu.Unlock()
+
+	if p.workers >= p.maxWorkers {
+		return false
+	}
+	p.spawn(true, first)
+
+	return true
+}
+
+func (p *Pool) work(elastic bool, first func(context.Context)) {
+	defer p.wg.Done()
+
+	if first != nil {
+		p.run(first)
+	}
+
+	var idle <-chan time.Time
+	for {
+		if elastic {
+			idle = time.After(p.opts.idleTimeout)
+		}
+
+		select {
+		case task, ok := <-p.tasks:
+			if !ok {
+				p.workerMu.Lock()
+				p.workers--
+				p.workerMu.Unlock()
+				return
+			}
+			p.run(task)
+		case <-idle:
+			p.workerMu.Lock()
+			if p.workers > p.opts.minWorkers {
+				p.workers--
+				p.workerMu.Unlock()
+				return
+			}
+			p.workerMu.Unlock()
+		}
+	}
+}
+
+func (p *Pool) run(task func(context.Context)) {
+	if p.discard.Load() {
+		p.dropped.Inc()
+		return
+	}
+
+	p.running.Inc()
+	defer p.running.Dec()
+
+	if err := gosafe.Run(func() { task(p.ctx) }); err != nil && p.opts.panicHandler != nil {
+		var pe *gosafe.PanicError
+		if errors.As(err, &pe) {
+			p.opts.panicHandler(pe)
+		}
+	}
+}
+
+// Submit 提交一个任务, 队列已满时的行为由 SubmitMode 决定. Pool 关闭后返回 ErrorPoolClosed
+func (p *Pool) Submit(task func(context.Context)) error {
+	return p.SubmitCtx(context.Background(), task)
+}
+
+// SubmitCtx 与 Submit 相同, 但在 SubmitBlock 模式下阻塞等待时可以通过 ctx 取消, 此时返回 ctx.Err();
+// 阻塞等待期间 Pool 被关闭时返回 ErrorPoolClosed
+func (p *Pool) SubmitCtx(ctx context.Context, task func(context.Context)) error {
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return ErrorPoolClosed
+	}
+	// 不在持有 closeMu 时阻塞, close 通过 submitting 等待提交者返回后再关闭 tasks
+	p.submitting.Add(1)
+	p.closeMu.RUnlock()
+	defer p.submitting.Done()
+
+	select {
+	case p.tasks <- task:
+		if len(p.tasks) != 0 {
+			p.grow(nil)
+		}
+		return nil
+	default:
+	}
+
+	// 没有空闲 worker 且队列已满时, 未达到上限则把任务直接交给新的 worker
+	if p.grow(task) {
+		return nil
+	}
+
+	switch p.opts.mode {
+	case SubmitNonBlock, SubmitDrop:
+		select {
+		case p.tasks <- task:
+			return nil
+		default:
+		}
+
+		if p.opts.mode == SubmitDrop {
+			p.dropped.Inc()
+			return nil
+		}
+		return ErrorPoolFull
+	default:
+		select {
+		case p.tasks <- task:
+			return nil
+		case <-p.closing:
+			return ErrorPoolClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Workers 返回当前 worker 数量
+func (p *Pool) Workers() int {
+	p.workerMu.Lock()
+	defer p.workerMu.Unlock()
+
+	return p.workers
+}
+
+// Running 返回正在执行的任务数量
+func (p *Pool) Running() int { return int(p.running.Load()) }
+
+// Waiting 返回队列中等待执行的任务数量
+func (p *Pool) Waiting() int { return len(p.tasks) }
+
+// Dropped 返回被丢弃的任务数量, 包括 SubmitDrop 模式下丢弃的任务和关闭时被取消的任务
+func (p *Pool) Dropped() int64 { return p.dropped.Load() }
+
+func (p *Pool) close() bool {
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return false
+	}
+	p.closed = true
+	close(p.closing)
+	p.closeMu.Unlock()
+
+	// closed 已经置位, 不会再有新的提交者; 已有的提交者会因 closing 返回
+	p.submitting.Wait()
+	close(p.tasks)
+
+	return true
+}
+
+func (p *Pool) wait() <-chan struct{} {
+	done := make(chan struct{})
+	go func() { p.wg.Wait(); close(done) }()
+	return done
+}
+
+// Shutdown 优雅关闭: 不再接受新任务, 等待队列中的任务全部执行完毕.
+// 如果 ctx 先结束, 则丢弃队列中尚未开始的任务, 取消正在执行任务的 ctx, 并返回 ctx.Err(), 不再等待正在执行的任务.
+// 重复关闭返回 ErrorPoolClosed.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if !p.close() {
+		return ErrorPoolClosed
+	}
+
+	select {
+	case <-p.wait():
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.discard.Store(true)
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// ShutdownNow 立即关闭: 不再接受新任务, 丢弃队列中尚未开始的任务, 取消正在执行任务的 ctx, 并等待它们返回.
+// 可以在 Shutdown 进行中调用, 用于放弃等待队列中的任务.
+func (p *Pool) ShutdownNow() {
+	p.discard.Store(true)
+	p.cancel()
+	p.close()
+	<-p.wait()
+}
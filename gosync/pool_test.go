@@ -0,0 +1,155 @@
+package gosync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestPool(t *testing.T) {
+	t.Run("limits concurrency", func(t *testing.T) {
+		p := NewPool(3, WithQueueSize(10))
+
+		var cur, peak, done atomic.Int64
+		for i := 0; i < 20; i++ {
+			require.NoError(t, p.Submit(func(context.Context) {
+				n := cur.Inc()
+				for {
+					old := peak.Load()
+					if n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				cur.Dec()
+				done.Inc()
+			}))
+		}
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		assert.Equal(t, int64(20), done.Load())
+		assert.LessOrEqual(t, peak.Load(), int64(3))
+		assert.Equal(t, ErrorPoolClosed, p.Submit(func(context.Context) {}))
+	})
+
+	t.Run("non-block and drop", func(t *testing.T) {
+		block := make(chan struct{})
+		p := NewPool(1, WithQueueSize(1), WithSubmitMode(SubmitNonBlock))
+		require.NoError(t, p.Submit(func(context.Context) { <-block }))
+		assert.Eventually(t, func() bool { return p.Running() == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, p.Submit(func(context.Context) {}))
+		assert.Equal(t, ErrorPoolFull, p.Submit(func(context.Context) {}))
+		close(block)
+		p.ShutdownNow()
+
+		block = make(chan struct{})
+		p = NewPool(1, WithQueueSize(1), WithSubmitMode(SubmitDrop))
+		require.NoError(t, p.Submit(func(context.Context) { <-block }))
+		assert.Eventually(t, func() bool { return p.Running() == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, p.Submit(func(context.Context) {}))
+		assert.NoError(t, p.Submit(func(context.Context) {}))
+		assert.Equal(t, int64(1), p.Dropped())
+		close(block)
+		p.ShutdownNow()
+	})
+
+	t.Run("elastic non-block and drop", func(t *testing.T) {
+		for _, mode := range []SubmitMode{SubmitNonBlock, SubmitDrop} {
+			block := make(chan struct{})
+			p := NewPool(4, WithMinWorkers(0), WithSubmitMode(mode))
+			for i := 0; i < 4; i++ {
+				require.NoError(t, p.Submit(func(context.Context) { <-block }))
+			}
+			assert.Equal(t, 4, p.Workers())
+			assert.Eventually(t, func() bool { return p.Running() == 4 }, time.Second, time.Millisecond)
+			assert.Equal(t, int64(0), p.Dropped())
+
+			if err := p.Submit(func(context.Context) {}); mode == SubmitNonBlock {
+				assert.Equal(t, ErrorPoolFull, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), p.Dropped())
+			}
+			close(block)
+			p.ShutdownNow()
+		}
+	})
+
+	t.Run("panic isolation", func(t *testing.T) {
+		var panics atomic.Int64
+		p := NewPool(1, WithQueueSize(2), WithPanicHandler(func(*gosafe.PanicError) { panics.Inc() }))
+
+		var ran atomic.Bool
+		require.NoError(t, p.Submit(func(context.Context) { panic("boom") }))
+		require.NoError(t, p.Submit(func(context.Context) { ran.Store(true) }))
+		require.NoError(t, p.Shutdown(context.Background()))
+
+		assert.Equal(t, int64(1), panics.Load())
+		assert.True(t, ran.Load())
+	})
+
+	t.Run("elastic workers", func(t *testing.T) {
+		p := NewPool(4, WithMinWorkers(1), WithIdleTimeout(10*time.Millisecond))
+		assert.Equal(t, 1, p.Workers())
+
+		block := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			require.NoError(t, p.Submit(func(context.Context) { <-block }))
+		}
+		assert.Eventually(t, func() bool { return p.Running() == 4 }, time.Second, time.Millisecond)
+		assert.Equal(t, 4, p.Workers())
+
+		close(block)
+		assert.Eventually(t, func() bool { return p.Workers() == 1 }, time.Second, time.Millisecond)
+		p.ShutdownNow()
+	})
+
+	t.Run("shutdown timeout cancels pending", func(t *testing.T) {
+		p := NewPool(1, WithQueueSize(5))
+
+		var ran atomic.Int64
+		for i := 0; i < 5; i++ {
+			require.NoError(t, p.Submit(func(ctx context.Context) {
+				ran.Inc()
+				<-ctx.Done()
+			}))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+
+		p.ShutdownNow()
+		assert.Equal(t, int64(1), ran.Load())
+		assert.Equal(t, int64(4), p.Dropped())
+	})
+
+	t.Run("shutdown with blocked submitter", func(t *testing.T) {
+		for _, now := range []bool{false, true} {
+			p := NewPool(1)
+			require.NoError(t, p.Submit(func(ctx context.Context) { <-ctx.Done() }))
+			assert.Eventually(t, func() bool { return p.Running() == 1 }, time.Second, time.Millisecond)
+
+			submitted := make(chan error, 1)
+			go func() { submitted <- p.Submit(func(context.Context) {}) }()
+			time.Sleep(10 * time.Millisecond)
+
+			start := time.Now()
+			if now {
+				p.ShutdownNow()
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+				cancel()
+			}
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+			assert.Equal(t, ErrorPoolClosed, <-submitted)
+			assert.Eventually(t, func() bool { return p.Workers() == 0 }, time.Second, time.Millisecond)
+		}
+	})
+}
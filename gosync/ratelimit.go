@@ -0,0 +1,312 @@
+package gosync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrorRateLimitWait  = errors.New("rate limit: wait would exceed context deadline")
+	ErrorRateLimitNever = errors.New("rate limit: quota will never be available")
+)
+
+const maxDuration = time.Duration(1<<63 - 1)
+
+// Limiter 是限流器的通用接口, TokenBucket 和 SlidingWindow 都实现了该接口
+type Limiter interface {
+	// Allow 判断当前是否可以立即执行一次, 可以时消耗一次配额并返回 true, 否则不消耗配额并返回 false
+	Allow() bool
+	// Wait 阻塞直到可以执行一次, ctx 结束或预计等待时间超过 ctx 的截止时间时返回错误且不消耗配额,
+	// 永远无法满足时返回 ErrorRateLimitNever
+	Wait(ctx context.Context) error
+	// Reserve 预订一次配额, 调用方需要等待 Reservation.Delay 之后再执行, 不执行时应当调用 Reservation.Cancel 归还配额
+	Reserve() *Reservation
+}
+
+// Reservation 表示一次预订的配额
+type Reservation struct {
+	ok     bool
+	at     time.Time
+	once   sync.Once
+	cancel func()
+}
+
+// OK 返回是否预订成功, 限流器永远无法满足预订时返回 false
+func (r *Reservation) OK() bool { return r.ok }
+
+// Delay 返回距离可以执行还需要等待的时间, 预订失败时返回 -1
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return -1
+	}
+
+	return max(time.Until(r.at), 0)
+}
+
+// Cancel 归还预订的配额, 多次调用只生效一次, 预订失败或已经到了可以执行的时间时无效
+func (r *Reservation) Cancel() {
+	if r.ok && r.cancel != nil && time.Now().Before(r.at) {
+		r.once.Do(r.cancel)
+	}
+}
+
+// waitReservation 是 Limiter.Wait 的通用实现, reserve 在预计可执行时间晚于 limit 时应当返回预订失败且不消耗配额
+func waitReservation(ctx context.Context, reserve func(limit time.Time) *Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	limit, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		limit = time.Now().Add(maxDuration)
+	}
+
+	r := reserve(limit)
+	if !r.ok {
+		if !hasDeadline {
+			return ErrorRateLimitNever
+		}
+		return ErrorRateLimitWait
+	}
+
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// TokenBucket 是令牌桶限流器: 令牌以 rate 个/秒的速度放入容量为 burst 的桶中, 每次执行消耗一个令牌.
+// 必须通过 NewTokenBucket 创建, 可以并发使用.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket 创建一个令牌桶, 初始时桶是满的.
+// rate 为每秒放入的令牌数, 小于等于 0 时不再补充令牌; burst 为桶的容量, 即允许的最大突发数量, 小于 1 时按 1 处理.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	b := float64(max(burst, 1))
+	return &TokenBucket{rate: max(rate, 0), burst: b, tokens: b, last: time.Now()}
+}
+
+// advance 根据流逝的时间补充令牌, 调用方需持有 mu
+func (t *TokenBucket) advance(now time.Time) {
+	if now.After(t.last) {
+		t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.rate, t.burst)
+		t.last = now
+	}
+}
+
+func (t *TokenBucket) reserve(now, limit time.Time) *Reservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.advance(now)
+
+	at := now
+	if t.tokens < 1 {
+		if t.rate == 0 {
+			return &Reservation{}
+		}
+		at = now.Add(time.Duration((1 - t.tokens) / t.rate * float64(time.Second)))
+	}
+	if at.After(limit) {
+		return &Reservation{}
+	}
+
+	t.tokens--
+
+	return &Reservation{ok: true, at: at, cancel: func() {
+		t.mu.Lock()
+		t.advance(time.Now())
+		t.tokens = min(t.tokens+1, t.burst)
+		t.mu.Unlock()
+	}}
+}
+
+func (t *TokenBucket) Allow() bool {
+	now := time.Now()
+	return t.reserve(now, now).ok
+}
+
+func (t *TokenBucket) Wait(ctx context.Context) error {
+	return waitReservation(ctx, func(limit time.Time) *Reservation { return t.reserve(time.Now(), limit) })
+}
+
+func (t *TokenBucket) Reserve() *Reservation {
+	return t.reserve(time.Now(), time.Now().Add(maxDuration))
+}
+
+// Tokens 返回当前桶中的令牌数, 存在未到期的预订时可能为负数
+func (t *TokenBucket) Tokens() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.advance(time.Now())
+
+	return t.tokens
+}
+
+// SlidingWindow 是滑动窗口限流器: 任意长度为 window 的时间段内最多执行 limit 次.
+// 必须通过 NewSlidingWindow 创建, 可以并发使用.
+type SlidingWindow struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	events []time.Time // 窗口内(包括已预订但尚未到期) 的执行时间, 升序
+}
+
+// NewSlidingWindow 创建一个滑动窗口限流器, limit 小于 1 时按 1 处理
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	limit = max(limit, 1)
+	return &SlidingWindow{limit: limit, window: window, events: make([]time.Time, 0, limit)}
+}
+
+func (s *SlidingWindow) reserve(now, limit time.Time) *Reservation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := sort.Search(len(s.events), func(i int) bool { return s.events[i].After(now.Add(-s.window)) })
+	s.events = append(s.events[:0], s.events[expired:]...)
+
+	at := now
+	if len(s.events) >= s.limit {
+		if next := s.events[len(s.events)-s.limit].Add(s.window); next.After(at) {
+			at = next
+		}
+	}
+	if at.After(limit) {
+		return &Reservation{}
+	}
+
+	s.events = append(s.events, at)
+
+	return &Reservation{ok: true, at: at, cancel: func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i := len(s.events) - 1; i >= 0; i-- {
+			if s.events[i].Equal(at) {
+				s.events = append(s.events[:i], s.events[i+1:]...)
+				return
+			}
+		}
+	}}
+}
+
+func (s *SlidingWindow) Allow() bool {
+	now := time.Now()
+	return s.reserve(now, now).ok
+}
+
+func (s *SlidingWindow) Wait(ctx context.Context) error {
+	return waitReservation(ctx, func(limit time.Time) *Reservation { return s.reserve(time.Now(), limit) })
+}
+
+func (s *SlidingWindow) Reserve() *Reservation {
+	return s.reserve(time.Now(), time.Now().Add(maxDuration))
+}
+
+type keyedLimiterEntry struct {
+	limiter Limiter
+	last    time.Time
+}
+
+// KeyedLimiter 为每个 key 维护一个独立的限流器, 限流器在 key 第一次使用时通过 newLimiter 创建,
+// 空闲超过 idle 的 key 会在之后的调用中被顺带清理, 也可以通过 Evict 主动清理.
+// idle 应当不小于限流器恢复到初始状态所需的时间(比如令牌桶的 burst/rate), 否则清理后重建的限流器会放宽限制.
+// 必须通过 NewKeyedLimiter 创建, 可以并发使用.
+type KeyedLimiter[K comparable] struct {
+	newLimiter func() Limiter
+	idle       time.Duration
+
+	mu        sync.Mutex
+	limiters  map[K]*keyedLimiterEntry
+	lastEvict time.Time
+}
+
+// NewKeyedLimiter 创建一个按 key 限流的限流器, idle 小于等于 0 时不清理
+func NewKeyedLimiter[K comparable](newLimiter func() Limiter, idle time.Duration) *KeyedLimiter[K] {
+	return &KeyedLimiter[K]{newLimiter: newLimiter, idle: idle, limiters: make(map[K]*keyedLimiterEntry), lastEvict: time.Now()}
+}
+
+func (k *KeyedLimiter[K]) get(key K) Limiter {
+	now := time.Now()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.idle > 0 && now.Sub(k.lastEvict) >= k.idle {
+		k.evict(now)
+	}
+
+	e, ok := k.limiters[key]
+	if !ok {
+		e = &keyedLimiterEntry{limiter: k.newLimiter()}
+		k.limiters[key] = e
+	}
+	e.last = now
+
+	return e.limiter
+}
+
+// evict 调用方需持有 mu
+func (k *KeyedLimiter[K]) evict(now time.Time) int {
+	k.lastEvict = now
+
+	n := 0
+	for key, e := range k.limiters {
+		if now.Sub(e.last) >= k.idle {
+			delete(k.limiters, key)
+			n++
+		}
+	}
+
+	return n
+}
+
+// Allow 对 key 对应的限流器调用 Allow
+func (k *KeyedLimiter[K]) Allow(key K) bool { return k.get(key).Allow() }
+
+// Wait 对 key 对应的限流器调用 Wait
+func (k *KeyedLimiter[K]) Wait(ctx context.Context, key K) error { return k.get(key).Wait(ctx) }
+
+// Reserve 对 key 对应的限流器调用 Reserve
+func (k *KeyedLimiter[K]) Reserve(key K) *Reservation { return k.get(key).Reserve() }
+
+// Len 返回当前维护的 key 数量
+func (k *KeyedLimiter[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.limiters)
+}
+
+// Evict 立即清理空闲超过 idle 的 key, 返回清理的数量; idle 小于等于 0 时不清理
+func (k *KeyedLimiter[K]) Evict() int {
+	if k.idle <= 0 {
+		return 0
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.evict(time.Now())
+}
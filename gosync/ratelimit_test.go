@@ -0,0 +1,103 @@
+package gosync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst then refill", func(t *testing.T) {
+		tb := NewTokenBucket(100, 3)
+		for i := 0; i < 3; i++ {
+			assert.True(t, tb.Allow())
+		}
+		assert.False(t, tb.Allow())
+
+		time.Sleep(15 * time.Millisecond)
+		assert.True(t, tb.Allow())
+	})
+
+	t.Run("reserve and cancel", func(t *testing.T) {
+		tb := NewTokenBucket(10, 1)
+		assert.True(t, tb.Allow())
+
+		r := tb.Reserve()
+		require.True(t, r.OK())
+		assert.InDelta(t, 100*time.Millisecond, r.Delay(), float64(20*time.Millisecond))
+		r.Cancel()
+		r.Cancel()
+		assert.InDelta(t, 0, tb.Tokens(), 0.1)
+
+		// 到期之后再取消不归还配额
+		tb = NewTokenBucket(1, 1)
+		r = tb.Reserve()
+		require.True(t, r.OK())
+		assert.Equal(t, time.Duration(0), r.Delay())
+		time.Sleep(time.Millisecond)
+		r.Cancel()
+		assert.Less(t, tb.Tokens(), 0.1)
+	})
+
+	t.Run("no refill", func(t *testing.T) {
+		tb := NewTokenBucket(0, 1)
+		assert.True(t, tb.Allow())
+		assert.False(t, tb.Reserve().OK())
+		assert.Equal(t, ErrorRateLimitNever, tb.Wait(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.Equal(t, ErrorRateLimitWait, tb.Wait(ctx))
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		tb := NewTokenBucket(50, 1)
+		assert.True(t, tb.Allow())
+
+		start := time.Now()
+		require.NoError(t, tb.Wait(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, ErrorRateLimitWait, tb.Wait(ctx))
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sw := NewSlidingWindow(2, 30*time.Millisecond)
+	assert.True(t, sw.Allow())
+	assert.True(t, sw.Allow())
+	assert.False(t, sw.Allow())
+
+	r := sw.Reserve()
+	require.True(t, r.OK())
+	assert.Greater(t, r.Delay(), time.Duration(0))
+	r.Cancel()
+
+	start := time.Now()
+	require.NoError(t, sw.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.True(t, sw.Allow())
+	assert.False(t, sw.Allow())
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	kl := NewKeyedLimiter[string](func() Limiter { return NewTokenBucket(1, 1) }, 20*time.Millisecond)
+
+	assert.True(t, kl.Allow("a"))
+	assert.False(t, kl.Allow("a"))
+	assert.True(t, kl.Allow("b"))
+	assert.Equal(t, 2, kl.Len())
+
+	time.Sleep(25 * time.Millisecond)
+	assert.Equal(t, 2, kl.Evict())
+	assert.Equal(t, 0, kl.Len())
+
+	assert.True(t, kl.Allow("a"))
+	time.Sleep(25 * time.Millisecond)
+	assert.True(t, kl.Allow("b"))
+	assert.Equal(t, 1, kl.Len())
+}
@@ -0,0 +1,356 @@
+// Package lifecycle 用于协调服务中各组件的启动和关闭: 组件以 Hook 的形式注册启动和关闭函数以及优先级,
+// 启动时按优先级从小到大依次执行 Start, 收到退出信号后按相反的顺序依次执行 Stop, 每个 Stop 有单独的超时时间,
+// 整个关闭过程也有总的超时时间, 最后返回一份报告, 列出失败或超时的 Hook. 调用方式:
+//
+//	m := lifecycle.New()
+//	m.Append(lifecycle.Hook{Name: "db", Priority: 0, Start: db.Open, Stop: db.Close})
+//	m.Append(lifecycle.Hook{Name: "http", Priority: 10, Start: srv.Start, Stop: srv.Shutdown})
+//	report, err := m.Run(context.Background())
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+var (
+	ErrorHookTimeout     = errors.New("hook timed out")
+	ErrorShutdownTimeout = errors.New("shutdown timed out before hook ran")
+	ErrorStarted         = errors.New("lifecycle already started")
+	ErrorStopped         = errors.New("lifecycle stopped during start")
+)
+
+// Hook 描述一个组件的启动和关闭
+type Hook struct {
+	Name     string
+	Priority int // 越小越先启动、越后关闭; 相同优先级按注册顺序启动, 按相反顺序关闭
+	// Start 启动组件, 应当在组件可用后返回, 长期运行的工作需要自行在后台进行. 为 nil 时跳过
+	Start func(ctx context.Context) error
+	// Stop 关闭组件, ctx 会在该 Hook 的超时时间或整个关闭过程的超时时间到达时结束. 为 nil 时跳过
+	Stop func(ctx context.Context) error
+	// StopTimeout 是 Stop 的超时时间, 小于等于 0 时使用 WithHookTimeout 设置的默认值
+	StopTimeout time.Duration
+}
+
+type options struct {
+	hookTimeout     time.Duration
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+}
+
+// Option 用于配置 Manager
+type Option func(*options)
+
+// WithHookTimeout 设置 Hook.Stop 默认的超时时间, 默认 10 秒
+func WithHookTimeout(d time.Duration) Option { return func(o *options) { o.hookTimeout = d } }
+
+// WithShutdownTimeout 设置整个关闭过程的超时时间, 超时后尚未执行的 Stop 不再执行, 默认 30 秒
+func WithShutdownTimeout(d time.Duration) Option { return func(o *options) { o.shutdownTimeout = d } }
+
+// WithSignals 设置 Run 监听的退出信号, 默认 SIGINT 和 SIGTERM
+func WithSignals(sigs ...os.Signal) Option { return func(o *options) { o.signals = sigs } }
+
+// Phase 表示 Hook 执行的阶段
+type Phase string
+
+const (
+	PhaseStart Phase = "start"
+	PhaseStop  Phase = "stop"
+)
+
+// HookResult 是一个 Hook 在某个阶段的执行结果
+type HookResult struct {
+	Name     string
+	Phase    Phase
+	Err      error // 超时时为 ErrorHookTimeout, ctx 被取消(包括因此未执行) 时为 context.Canceled, 因整体超时而未执行时为 ErrorShutdownTimeout, panic 时为 *gosafe.PanicError
+	Duration time.Duration
+}
+
+// Report 是启动和关闭过程的报告, 按执行顺序记录每个 Hook 的结果
+type Report struct {
+	Results []HookResult
+}
+
+// Failed 返回所有失败(包括超时) 的结果
+func (r *Report) Failed() []HookResult {
+	var failed []HookResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// Err 把所有失败的结果合并为一个错误, 没有失败时返回 nil
+func (r *Report) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s %s: %w", res.Phase, res.Name, res.Err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, res := range r.Results {
+		status := "ok"
+		if res.Err != nil {
+			status = res.Err.Error()
+		}
+		fmt.Fprintf(&sb, "%s %s (%v): %s\n", res.Phase, res.Name, res.Duration, status)
+	}
+
+	return sb.String()
+}
+
+// Manager 管理一组 Hook 的启动和关闭, 必须通过 New 创建.
+// Append 需要在 Start 之前调用; Start 和 Stop 都只会生效一次.
+type Manager struct {
+	opts options
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started []Hook // 已经成功启动的 Hook, 按启动顺序
+	state   int    // 0: 未启动, 1: 已启动, 2: 已关闭
+	report  Report
+}
+
+// New 创建一个 Manager
+func New(opts ...Option) *Manager {
+	o := options{
+		hookTimeout:     10 * time.Second,
+		shutdownTimeout: 30 * time.Second,
+		signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Manager{opts: o}
+}
+
+// Append 注册一个 Hook, 启动之后注册返回 ErrorStarted
+func (m *Manager) Append(h Hook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state != 0 {
+		return ErrorStarted
+	}
+	m.hooks = append(m.hooks, h)
+
+	return nil
+}
+
+// call 执行一个 Hook 函数, timeout 大于 0 时超时后不再等待其返回, ctx 被取消时同样不再等待
+func call(ctx context.Context, name string, phase Phase, f func(context.Context) error, timeout time.Duration) HookResult {
+	res := HookResult{Name: name, Phase: phase}
+	if f == nil {
+		return res
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	dc := make(chan error, 1)
+	gosafe.Go(func() {
+		var err error
+		if perr := gosafe.Run(func() { err = f(ctx) }); perr != nil {
+			err = perr
+		}
+		dc <- err
+	})
+
+	select {
+	case res.Err = <-dc:
+	case <-ctx.Done():
+		// Hook 可能在 ctx 结束的同时返回, 优先采用它的结果
+		select {
+		case res.Err = <-dc:
+		default:
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				res.Err = ErrorHookTimeout
+			} else {
+				res.Err = ctx.Err()
+			}
+		}
+	}
+	res.Duration = time.Since(start)
+
+	return res
+}
+
+// Start 按优先级依次启动所有 Hook, 某个 Hook 启动失败时, 按相反顺序关闭已经启动的 Hook, 并返回启动失败的错误.
+// ctx 被取消时正在执行的 Hook 视为启动失败. 启动过程中调用了 Stop 时, 关闭刚刚启动的 Hook, 不再启动剩余的 Hook, 并返回 ErrorStopped.
+// 结果记录在 Report 中.
+func (m *Manager) Start(ctx context.Context) error {
+	err := m.start(ctx)
+	if err != nil {
+		m.Stop(context.WithoutCancel(ctx))
+	}
+
+	return err
+}
+
+// start 依次启动所有 Hook, 启动失败时不关闭已经启动的 Hook, 由调用方调用 Stop
+func (m *Manager) start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.state != 0 {
+		m.mu.Unlock()
+		return ErrorStarted
+	}
+	m.state = 1
+
+	hooks := make([]Hook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.Unlock()
+
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Priority < hooks[j].Priority })
+
+	for _, h := range hooks {
+		res := call(ctx, h.Name, PhaseStart, h.Start, 0)
+
+		m.mu.Lock()
+		stopped := m.state == 2
+		m.report.Results = append(m.report.Results, res)
+		if res.Err == nil && !stopped {
+			m.started = append(m.started, h)
+		}
+		m.mu.Unlock()
+
+		if stopped {
+			// Stop 已经关闭了此前启动的 Hook, 这里只需关闭刚刚启动的 Hook
+			if res.Err == nil {
+				res = call(context.WithoutCancel(ctx), h.Name, PhaseStop, h.Stop, m.stopTimeout(h))
+				m.mu.Lock()
+				m.report.Results = append(m.report.Results, res)
+				m.mu.Unlock()
+			}
+			return ErrorStopped
+		}
+
+		if res.Err != nil {
+			return fmt.Errorf("start %s: %w", h.Name, res.Err)
+		}
+	}
+
+	return nil
+}
+
+// Stop 按启动的相反顺序依次关闭所有已经启动的 Hook, 每个 Hook 受自己的超时时间限制, 整个过程受关闭超时时间和 ctx 限制,
+// 超时后剩余的 Hook 不再执行. 返回包括启动和关闭过程的报告快照, 之后的变化不影响已经返回的报告;
+// 重复调用只返回最新的报告, 不会再次关闭.
+func (m *Manager) Stop(ctx context.Context) *Report {
+	m.mu.Lock()
+	if m.state == 2 {
+		defer m.mu.Unlock()
+		return m.snapshot()
+	}
+	m.state = 2
+	started := m.started
+	m.mu.Unlock()
+
+	if m.opts.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.opts.shutdownTimeout)
+		defer cancel()
+	}
+
+	for i := len(started) - 1; i >= 0; i-- {
+		h := started[i]
+
+		var res HookResult
+		if err := ctx.Err(); errors.Is(err, context.DeadlineExceeded) {
+			res = HookResult{Name: h.Name, Phase: PhaseStop, Err: ErrorShutdownTimeout}
+		} else if err != nil {
+			res = HookResult{Name: h.Name, Phase: PhaseStop, Err: err}
+		} else {
+			res = call(ctx, h.Name, PhaseStop, h.Stop, m.stopTimeout(h))
+		}
+
+		m.mu.Lock()
+		m.report.Results = append(m.report.Results, res)
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot()
+}
+
+// snapshot 复制当前的报告, 调用方需持有 mu
+func (m *Manager) snapshot() *Report {
+	return &Report{Results: append([]HookResult(nil), m.report.Results...)}
+}
+
+func (m *Manager) stopTimeout(h Hook) time.Duration {
+	if h.StopTimeout > 0 {
+		return h.StopTimeout
+	}
+
+	return m.opts.hookTimeout
+}
+
+// Run 启动所有 Hook, 然后等待退出信号或 ctx 结束, 再关闭所有 Hook.
+// 启动过程中收到退出信号或 ctx 结束时, 取消传给 Start 的 ctx, 正在执行的 Hook 视为启动失败, 然后关闭已经启动的 Hook;
+// 关闭过程中再次收到退出信号时, 放弃等待剩余的 Hook 立即返回.
+// 返回值:
+//
+//	关闭报告; 启动失败时返回启动错误, 否则返回报告中的失败合并后的错误
+func (m *Manager) Run(ctx context.Context) (*Report, error) {
+	sigc := make(chan os.Signal, 2)
+	signal.Notify(sigc, m.opts.signals...)
+	defer signal.Stop(sigc)
+
+	startCtx, cancelStart := context.WithCancel(ctx)
+	defer cancelStart()
+	stopCtx, cancelStop := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelStop()
+
+	// 第一次收到信号(或 ctx 结束) 时取消启动并通知关闭, 第二次收到信号时放弃关闭
+	shutdown := make(chan struct{})
+	gosafe.Go(func() {
+		select {
+		case <-sigc:
+		case <-ctx.Done():
+		case <-stopCtx.Done():
+			return
+		}
+		cancelStart()
+		close(shutdown)
+
+		select {
+		case <-sigc:
+			cancelStop()
+		case <-stopCtx.Done():
+		}
+	})
+
+	// 启动失败时由这里关闭, 使第二次信号同样可以打断关闭过程
+	if err := m.start(startCtx); err != nil {
+		return m.Stop(stopCtx), err
+	}
+
+	<-shutdown
+	report := m.Stop(stopCtx)
+
+	return report, report.Err()
+}
@@ -0,0 +1,214 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, priority int, startErr, stopErr error) Hook {
+	return Hook{
+		Name:     name,
+		Priority: priority,
+		Start:    func(context.Context) error { r.add("start " + name); return startErr },
+		Stop:     func(context.Context) error { r.add("stop " + name); return stopErr },
+	}
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	r.calls = append(r.calls, s)
+	r.mu.Unlock()
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.calls...)
+}
+
+func TestManagerOrder(t *testing.T) {
+	var r recorder
+	m := New()
+	require.NoError(t, m.Append(r.hook("http", 10, nil, nil)))
+	require.NoError(t, m.Append(r.hook("db", 0, nil, nil)))
+	require.NoError(t, m.Append(r.hook("cache", 0, nil, errors.New("flush failed"))))
+
+	require.NoError(t, m.Start(context.Background()))
+	assert.Equal(t, ErrorStarted, m.Start(context.Background()))
+	assert.Equal(t, ErrorStarted, m.Append(Hook{Name: "late"}))
+
+	report := m.Stop(context.Background())
+	assert.Equal(t, []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"}, r.get())
+	require.Len(t, report.Results, 6)
+	require.Len(t, report.Failed(), 1)
+	assert.Equal(t, "cache", report.Failed()[0].Name)
+	assert.Equal(t, PhaseStop, report.Failed()[0].Phase)
+	assert.ErrorContains(t, report.Err(), "stop cache: flush failed")
+
+	assert.Equal(t, report, m.Stop(context.Background()))
+	assert.Len(t, r.get(), 6)
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	var r recorder
+	startErr := errors.New("connect refused")
+	m := New()
+	require.NoError(t, m.Append(r.hook("a", 0, nil, nil)))
+	require.NoError(t, m.Append(r.hook("b", 1, startErr, nil)))
+	require.NoError(t, m.Append(r.hook("c", 2, nil, nil)))
+
+	err := m.Start(context.Background())
+	assert.ErrorIs(t, err, startErr)
+	// 启动失败的 b 不需要关闭, c 没有启动
+	assert.Equal(t, []string{"start a", "start b", "stop a"}, r.get())
+}
+
+func TestManagerStopDuringStart(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	var r recorder
+	entered, release := make(chan struct{}), make(chan struct{})
+	m := New()
+	require.NoError(t, m.Append(r.hook("first", 0, nil, nil)))
+	a := r.hook("a", 1, nil, nil)
+	start := a.Start
+	a.Start = func(ctx context.Context) error { close(entered); <-release; return start(ctx) }
+	require.NoError(t, m.Append(a))
+	require.NoError(t, m.Append(r.hook("b", 2, nil, nil)))
+
+	errc := make(chan error, 1)
+	go func() { errc <- m.Start(context.Background()) }()
+	<-entered
+
+	report := m.Stop(context.Background())
+	assert.Len(t, report.Results, 2, "first 的启动和关闭")
+	close(release)
+
+	assert.Equal(t, ErrorStopped, <-errc)
+	assert.Equal(t, []string{"start first", "stop first", "start a", "stop a"}, r.get())
+	assert.Len(t, report.Results, 2, "已经返回的报告不受影响")
+	assert.Len(t, m.Stop(context.Background()).Results, 4)
+}
+
+func TestManagerTimeout(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	var r recorder
+	m := New(WithHookTimeout(20*time.Millisecond), WithShutdownTimeout(50*time.Millisecond))
+	block := func(ctx context.Context) error { <-ctx.Done(); time.Sleep(10 * time.Millisecond); return nil }
+	require.NoError(t, m.Append(r.hook("first", 0, nil, nil)))
+	require.NoError(t, m.Append(Hook{Name: "slow", Priority: 1, Stop: block, StopTimeout: time.Second}))
+	require.NoError(t, m.Append(Hook{Name: "stuck", Priority: 2, Stop: block}))
+	require.NoError(t, m.Append(Hook{Name: "panic", Priority: 3, Stop: func(context.Context) error { panic("boom") }}))
+
+	require.NoError(t, m.Start(context.Background()))
+	report := m.Stop(context.Background())
+
+	failed := report.Failed()
+	require.Len(t, failed, 4)
+	var perr *gosafe.PanicError
+	assert.ErrorAs(t, failed[0].Err, &perr)
+	assert.Equal(t, "stuck", failed[1].Name)
+	assert.Equal(t, ErrorHookTimeout, failed[1].Err)
+	// slow 自己的超时更长, 受整体超时限制
+	assert.Equal(t, "slow", failed[2].Name)
+	assert.Equal(t, ErrorHookTimeout, failed[2].Err)
+	assert.Equal(t, "first", failed[3].Name)
+	assert.Equal(t, ErrorShutdownTimeout, failed[3].Err)
+	assert.Equal(t, []string{"start first"}, r.get())
+}
+
+func TestManagerRun(t *testing.T) {
+	var r recorder
+	started := make(chan struct{})
+	m := New(WithSignals(syscall.SIGUSR1))
+	require.NoError(t, m.Append(r.hook("a", 0, nil, nil)))
+	require.NoError(t, m.Append(Hook{Name: "ready", Priority: 1, Start: func(context.Context) error { close(started); return nil }}))
+
+	go func() {
+		<-started
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+
+	report, err := m.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start a", "stop a"}, r.get())
+	assert.Len(t, report.Results, 4)
+
+	// 启动卡住时, 退出信号可以打断启动
+	r = recorder{}
+	m = New(WithSignals(syscall.SIGUSR1))
+	require.NoError(t, m.Append(r.hook("a", 0, nil, nil)))
+	entered := make(chan struct{})
+	require.NoError(t, m.Append(Hook{Name: "hung", Priority: 1, Start: func(ctx context.Context) error {
+		close(entered)
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	go func() {
+		<-entered
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+
+	report, err = m.Run(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"start a", "stop a"}, r.get())
+	require.Len(t, report.Failed(), 1)
+	assert.Equal(t, "hung", report.Failed()[0].Name)
+
+	// 启动时收到信号后开始关闭, 再次收到信号时放弃等待剩余的 Hook
+	r = recorder{}
+	m = New(WithSignals(syscall.SIGUSR1), WithHookTimeout(2*time.Second))
+	require.NoError(t, m.Append(r.hook("db", 0, nil, nil)))
+	stopping := make(chan struct{})
+	require.NoError(t, m.Append(Hook{Name: "cache", Priority: 1, Stop: func(ctx context.Context) error {
+		close(stopping)
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	starting := make(chan struct{})
+	require.NoError(t, m.Append(Hook{Name: "hung", Priority: 2, Start: func(ctx context.Context) error {
+		close(starting)
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	go func() {
+		<-starting
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		<-stopping
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	}()
+
+	begin := time.Now()
+	report, err = m.Run(context.Background())
+	assert.Less(t, time.Since(begin), time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"start db"}, r.get())
+	failed := report.Failed()
+	require.Len(t, failed, 3)
+	assert.Equal(t, "cache", failed[1].Name)
+	assert.ErrorIs(t, failed[1].Err, context.Canceled)
+	assert.Equal(t, "db", failed[2].Name)
+	assert.ErrorIs(t, failed[2].Err, context.Canceled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err = New().Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Results)
+}
@@ -1,16 +1,171 @@
 package ecode
 
-import "errors"
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
 
 // Ecode represents an error type identified by a string code.
 // It is designed to work with the standard errors.Is function.
 // Two Ecode instances are considered equal by errors.Is if they are both of type Ecode
 // and were created with the same code string.
-// Ecode is intended to be a source of errors, not a wrapper around other errors,
-// so it does not implement the Unwrap method.
-type Ecode struct{ error }
+// Besides the code, an Ecode created by Define carries a stable numeric code, a Category,
+// an HTTP status and localisable message templates, none of which take part in equality.
+// An Ecode created by New or Define is a source of errors; Wrap returns a copy that also carries
+// an underlying cause, which is reachable through Unwrap for errors.Is/As and logging.
+type Ecode struct {
+	code     string
+	num      int
+	category Category
+	status   int
+	messages map[string]string // 语言 -> 信息模板, "" 为默认模板
+	cause    error
+}
 
-func New(ecode string) *Ecode { return &Ecode{error: errors.New(ecode)} }
+// New creates an Ecode identified by code. Its Error() is code itself, its category is CategoryUnknown,
+// and Num() is code parsed as an integer (0 if code is not numeric).
+func New(ecode string) *Ecode {
+	num, _ := strconv.Atoi(ecode)
+	return &Ecode{code: ecode, num: num, category: CategoryUnknown}
+}
+
+// Option configures an Ecode created by Define.
+type Option func(*Ecode)
+
+// WithStatus overrides the HTTP status derived from the category.
+func WithStatus(status int) Option { return func(e *Ecode) { e.status = status } }
+
+// WithMessage adds the message template for lang, e.g. "zh" or "zh-CN". See Message for the template syntax.
+func WithMessage(lang, template string) Option {
+	return func(e *Ecode) { e.messages[strings.ToLower(lang)] = template }
+}
+
+// Define creates an Ecode with a stable numeric code. Its string code is the decimal form of num,
+// so Define(1001, ...) and New("1001") are equal under errors.Is.
+// 入参:
+//
+//	num: 数字错误码, 对外稳定, 不应修改
+//	category: 错误类别, 决定默认的 HTTP 状态码和 gRPC 状态码
+//	message: 默认的信息模板, 在没有对应语言的模板时使用
+func Define(num int, category Category, message string, opts ...Option) *Ecode {
+	e := &Ecode{code: strconv.Itoa(num), num: num, category: category, messages: map[string]string{"": message}}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Wrap returns a copy of code carrying cause, so that the business code can be attached without losing
+// the original error: errors.Is(err, code) still matches by code, while errors.Is/As also reach cause.
+// If code already carries a cause, it is replaced. Wrap returns nil if cause is nil.
+func Wrap(code *Ecode, cause error) error {
+	if cause == nil {
+		return nil
+	}
+
+	w := *code
+	w.cause = cause
+
+	return &w
+}
+
+// Error returns the code, followed by the default message template if there is one,
+// followed by the cause if the Ecode was created by Wrap.
+func (e *Ecode) Error() string {
+	s := e.code
+	if msg := e.messages[""]; msg != "" {
+		s += ": " + msg
+	}
+	if e.cause != nil {
+		s += ": " + e.cause.Error()
+	}
+
+	return s
+}
+
+// Unwrap returns the cause passed to Wrap, or nil.
+func (e *Ecode) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+
+	return e.cause
+}
+
+// Code returns the string code used for equality.
+func (e *Ecode) Code() string { return e.code }
+
+// Num returns the numeric code.
+func (e *Ecode) Num() int { return e.num }
+
+// Category returns the category of the code.
+func (e *Ecode) Category() Category { return e.category }
+
+// HTTPStatus returns the status set by WithStatus, or the one derived from the category.
+func (e *Ecode) HTTPStatus() int {
+	if e.status != 0 {
+		return e.status
+	}
+
+	return e.category.HTTPStatus()
+}
+
+// GRPCCode returns the gRPC status code derived from the category, as the numeric value of codes.Code.
+func (e *Ecode) GRPCCode() uint32 { return e.category.GRPCCode() }
+
+// Message renders the message template for lang with params.
+// 模板的选择顺序: lang 完全匹配(不区分大小写), lang 的主语言(如 "zh-CN" 的 "zh"), 默认模板; 都没有时返回 Code().
+// 模板中的 {key} 会被替换为 params[key] 的 fmt 格式, params 中不存在的 key 保持原样.
+func (e *Ecode) Message(lang string, params map[string]any) string {
+	lang = strings.ToLower(lang)
+	tmpl, ok := e.messages[lang]
+	if !ok {
+		if base, _, found := strings.Cut(lang, "-"); found {
+			tmpl, ok = e.messages[base]
+		}
+	}
+	if !ok {
+		tmpl, ok = e.messages[""]
+	}
+	if !ok || tmpl == "" {
+		return e.code
+	}
+
+	return render(tmpl, params)
+}
+
+func render(tmpl string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		sb.WriteString(tmpl[:start])
+		if v, ok := params[tmpl[start+1:end]]; ok {
+			sb.WriteString(fmt.Sprint(v))
+		} else {
+			sb.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+	sb.WriteString(tmpl)
+
+	return sb.String()
+}
 
 // Is 实现了 errors.Is 的接口。
 // 如果目标错误也是 Ecode 类型且 code 相同，则返回 true。
@@ -29,5 +184,64 @@ func (e *Ecode) Is(target error) bool {
 	}
 
 	// 两个指针都不是 nil，可以安全地比较 code。
-	return e.Error() == et.Error()
-}
\ No newline at end of file
+	return e.code == et.code
+}
+
+// Category classifies an Ecode and determines its default HTTP and gRPC status.
+type Category string
+
+const (
+	CategoryUnknown            Category = "unknown"
+	CategoryCanceled           Category = "canceled"
+	CategoryInvalidArgument    Category = "invalid_argument"
+	CategoryDeadlineExceeded   Category = "deadline_exceeded"
+	CategoryNotFound           Category = "not_found"
+	CategoryAlreadyExists      Category = "already_exists"
+	CategoryPermissionDenied   Category = "permission_denied"
+	CategoryResourceExhausted  Category = "resource_exhausted"
+	CategoryFailedPrecondition Category = "failed_precondition"
+	CategoryAborted            Category = "aborted"
+	CategoryUnimplemented      Category = "unimplemented"
+	CategoryInternal           Category = "internal"
+	CategoryUnavailable        Category = "unavailable"
+	CategoryUnauthenticated    Category = "unauthenticated"
+)
+
+// categoryStatus 是类别到 HTTP 状态码和 gRPC 状态码的映射, 与 grpc-gateway 的映射一致
+var categoryStatus = map[Category]struct {
+	http int
+	grpc uint32
+}{
+	CategoryUnknown:            {http.StatusInternalServerError, 2},
+	CategoryCanceled:           {499, 1},
+	CategoryInvalidArgument:    {http.StatusBadRequest, 3},
+	CategoryDeadlineExceeded:   {http.StatusGatewayTimeout, 4},
+	CategoryNotFound:           {http.StatusNotFound, 5},
+	CategoryAlreadyExists:      {http.StatusConflict, 6},
+	CategoryPermissionDenied:   {http.StatusForbidden, 7},
+	CategoryResourceExhausted:  {http.StatusTooManyRequests, 8},
+	CategoryFailedPrecondition: {http.StatusBadRequest, 9},
+	CategoryAborted:            {http.StatusConflict, 10},
+	CategoryUnimplemented:      {http.StatusNotImplemented, 12},
+	CategoryInternal:           {http.StatusInternalServerError, 13},
+	CategoryUnavailable:        {http.StatusServiceUnavailable, 14},
+	CategoryUnauthenticated:    {http.StatusUnauthorized, 16},
+}
+
+// HTTPStatus returns the default HTTP status of the category; unknown categories map to 500.
+func (c Category) HTTPStatus() int {
+	if s, ok := categoryStatus[c]; ok {
+		return s.http
+	}
+
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC status code of the category; unknown categories map to Unknown (2).
+func (c Category) GRPCCode() uint32 {
+	if s, ok := categoryStatus[c]; ok {
+		return s.grpc
+	}
+
+	return 2
+}
@@ -0,0 +1,81 @@
+package ecode
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefine(t *testing.T) {
+	userNotFound := Define(1001, CategoryNotFound, "user {name} not found",
+		WithMessage("zh", "用户 {name} 不存在"), WithMessage("zh-TW", "使用者 {name} 不存在"))
+
+	assert.Equal(t, "1001", userNotFound.Code())
+	assert.Equal(t, 1001, userNotFound.Num())
+	assert.Equal(t, CategoryNotFound, userNotFound.Category())
+	assert.Equal(t, http.StatusNotFound, userNotFound.HTTPStatus())
+	assert.Equal(t, uint32(5), userNotFound.GRPCCode())
+	assert.Equal(t, "1001: user {name} not found", userNotFound.Error())
+
+	assert.ErrorIs(t, userNotFound, New("1001"))
+	assert.ErrorIs(t, fmt.Errorf("get: %w", New("1001")), userNotFound)
+	assert.NotErrorIs(t, userNotFound, Define(1002, CategoryNotFound, "user {name} not found"))
+
+	params := map[string]any{"name": "bob", "unused": 1}
+	assert.Equal(t, "user bob not found", userNotFound.Message("", params))
+	assert.Equal(t, "user bob not found", userNotFound.Message("fr", params))
+	assert.Equal(t, "用户 bob 不存在", userNotFound.Message("zh-CN", params))
+	assert.Equal(t, "使用者 bob 不存在", userNotFound.Message("ZH-tw", params))
+	assert.Equal(t, "user {name} not found", userNotFound.Message("en", nil))
+	assert.Equal(t, "{a} {b", render("{a} {b", map[string]any{"x": 1}))
+
+	limited := Define(2001, CategoryResourceExhausted, "", WithStatus(http.StatusServiceUnavailable))
+	assert.Equal(t, http.StatusServiceUnavailable, limited.HTTPStatus())
+	assert.Equal(t, "2001", limited.Error())
+	assert.Equal(t, "2001", limited.Message("en", nil))
+}
+
+func TestNew(t *testing.T) {
+	e := New("circuit breaker open")
+	assert.Equal(t, "circuit breaker open", e.Error())
+	assert.Equal(t, 0, e.Num())
+	assert.Equal(t, CategoryUnknown, e.Category())
+	assert.Equal(t, http.StatusInternalServerError, e.HTTPStatus())
+	assert.Equal(t, "circuit breaker open", e.Message("en", nil))
+	assert.Equal(t, 42, New("42").Num())
+
+	assert.Equal(t, http.StatusInternalServerError, Category("custom").HTTPStatus())
+	assert.Equal(t, uint32(2), Category("custom").GRPCCode())
+	assert.False(t, errors.Is(e, errors.New("circuit breaker open")))
+}
+
+func TestWrap(t *testing.T) {
+	code := Define(3001, CategoryUnavailable, "storage unavailable")
+	cause := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	assert.Nil(t, Wrap(code, nil))
+
+	err := Wrap(code, cause)
+	assert.Equal(t, "3001: storage unavailable: dial: connection refused", err.Error())
+	assert.ErrorIs(t, err, code)
+	assert.ErrorIs(t, fmt.Errorf("save: %w", err), New("3001"))
+	assert.ErrorIs(t, err, cause)
+
+	var opErr *net.OpError
+	assert.ErrorAs(t, err, &opErr)
+	var e *Ecode
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, CategoryUnavailable, e.Category())
+	assert.Same(t, cause, errors.Unwrap(err))
+
+	// code 本身不受影响
+	assert.Nil(t, code.Unwrap())
+	assert.Equal(t, "3001: storage unavailable", code.Error())
+
+	assert.Equal(t, "x: y", Wrap(New("x"), errors.New("y")).Error())
+}
@@ -0,0 +1,59 @@
+package ecode
+
+// walk 按深度优先的顺序遍历 err 的错误链(包括 errors.Join 等实现了 Unwrap() []error 的错误), 对每个 *Ecode 调用 f
+func walk(err error, depth int, f func(e *Ecode, depth int)) {
+	if err == nil {
+		return
+	}
+
+	if e, ok := err.(*Ecode); ok && e != nil {
+		f(e, depth)
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walk(u.Unwrap(), depth+1, f)
+	case interface{ Unwrap() []error }:
+		for _, err := range u.Unwrap() {
+			walk(err, depth+1, f)
+		}
+	}
+}
+
+// From returns the outermost Ecode in err's chain, i.e. the one closest to err, which is usually the code
+// assigned by the layer nearest to the caller. Branches of errors.Join are searched in order,
+// and among Ecodes at the same depth the first one wins.
+func From(err error) (*Ecode, bool) {
+	var found *Ecode
+	best := 0
+	walk(err, 0, func(e *Ecode, depth int) {
+		if found == nil || depth < best {
+			found, best = e, depth
+		}
+	})
+
+	return found, found != nil
+}
+
+// FromInnermost returns the innermost Ecode in err's chain, i.e. the one closest to the root cause.
+// Among Ecodes at the same depth the first one wins.
+func FromInnermost(err error) (*Ecode, bool) {
+	var found *Ecode
+	best := 0
+	walk(err, 0, func(e *Ecode, depth int) {
+		if found == nil || depth > best {
+			found, best = e, depth
+		}
+	})
+
+	return found, found != nil
+}
+
+// CodeOf returns From(err), or def if err carries no Ecode.
+func CodeOf(err error, def *Ecode) *Ecode {
+	if e, ok := From(err); ok {
+		return e
+	}
+
+	return def
+}
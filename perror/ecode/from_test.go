@@ -0,0 +1,44 @@
+package ecode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	outer, inner, other := New("outer"), New("inner"), New("other")
+	def := New("default")
+
+	_, ok := From(nil)
+	assert.False(t, ok)
+	_, ok = From(io.EOF)
+	assert.False(t, ok)
+	assert.Same(t, def, CodeOf(io.EOF, def))
+	assert.Nil(t, CodeOf(nil, nil))
+
+	var typedNil *Ecode
+	_, ok = From(fmt.Errorf("x: %w", typedNil))
+	assert.False(t, ok)
+
+	err := fmt.Errorf("handler: %w", Wrap(outer, fmt.Errorf("repo: %w", Wrap(inner, io.EOF))))
+	e, ok := From(err)
+	assert.True(t, ok)
+	assert.Equal(t, "outer", e.Code())
+	e, ok = FromInnermost(err)
+	assert.True(t, ok)
+	assert.Equal(t, "inner", e.Code())
+	assert.Equal(t, "outer", CodeOf(err, def).Code())
+
+	joined := errors.Join(io.EOF, fmt.Errorf("a: %w", fmt.Errorf("b: %w", inner)), fmt.Errorf("c: %w", other))
+	e, _ = From(joined)
+	assert.Same(t, other, e)
+	e, _ = FromInnermost(joined)
+	assert.Same(t, inner, e)
+
+	e, _ = From(errors.Join(other, inner))
+	assert.Same(t, other, e)
+}
@@ -0,0 +1,104 @@
+package ecode
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+var ErrorDuplicateCode = errors.New("duplicate error code")
+
+type registration struct {
+	ecode *Ecode
+	file  string
+	line  int
+}
+
+// Registry records declared Ecodes so that every code is declared exactly once. It is safe for concurrent use.
+// Most programs use the package-level Register, MustRegister, Lookup and All, which share a default Registry.
+type Registry struct {
+	mu    sync.RWMutex
+	codes map[string]registration
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry { return &Registry{codes: make(map[string]registration)} }
+
+var defaultRegistry = NewRegistry()
+
+// Register adds e to the default registry. See (*Registry).Register.
+func Register(e *Ecode) (*Ecode, error) { return defaultRegistry.register(e, 1) }
+
+// MustRegister adds e to the default registry and panics on duplicates. See (*Registry).MustRegister.
+func MustRegister(e *Ecode) *Ecode { return defaultRegistry.mustRegister(e, 1) }
+
+// Lookup returns the Ecode with the given code from the default registry.
+func Lookup(code string) (*Ecode, bool) { return defaultRegistry.Lookup(code) }
+
+// All returns all Ecodes in the default registry. See (*Registry).All.
+func All() []*Ecode { return defaultRegistry.All() }
+
+// Register adds e to r.
+// If an Ecode with the same code is already registered, it returns an error wrapping ErrorDuplicateCode
+// that names the location of the first registration.
+func (r *Registry) Register(e *Ecode) (*Ecode, error) { return r.register(e, 1) }
+
+// MustRegister is like Register but panics on duplicates. It is meant for package-level declarations,
+// so that collisions are reported at init:
+//
+//	var ErrorUserNotFound = ecode.MustRegister(ecode.Define(1001, ecode.CategoryNotFound, "user {name} not found"))
+func (r *Registry) MustRegister(e *Ecode) *Ecode { return r.mustRegister(e, 1) }
+
+func (r *Registry) mustRegister(e *Ecode, skip int) *Ecode {
+	e, err := r.register(e, skip+1)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+// register 记录 e 以及调用位置, skip 为 register 的调用方之上需要跳过的层数
+func (r *Registry) register(e *Ecode, skip int) (*Ecode, error) {
+	_, file, line, _ := runtime.Caller(skip + 1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if first, ok := r.codes[e.code]; ok {
+		return nil, fmt.Errorf("%w: %q at %s:%d, first registered at %s:%d", ErrorDuplicateCode, e.code, file, line, first.file, first.line)
+	}
+	r.codes[e.code] = registration{ecode: e, file: file, line: line}
+
+	return e, nil
+}
+
+// Lookup returns the registered Ecode with the given code.
+func (r *Registry) Lookup(code string) (*Ecode, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reg, ok := r.codes[code]
+	return reg.ecode, ok
+}
+
+// All returns all registered Ecodes ordered by numeric code and then by code, e.g. for generating API error documentation.
+func (r *Registry) All() []*Ecode {
+	r.mu.RLock()
+	all := make([]*Ecode, 0, len(r.codes))
+	for _, reg := range r.codes {
+		all = append(all, reg.ecode)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].num != all[j].num {
+			return all[i].num < all[j].num
+		}
+		return all[i].code < all[j].code
+	})
+
+	return all
+}
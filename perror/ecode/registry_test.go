@@ -0,0 +1,47 @@
+package ecode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	notFound := r.MustRegister(Define(91001, CategoryNotFound, "not found"))
+	invalid, err := r.Register(Define(91000, CategoryInvalidArgument, "invalid"))
+	require.NoError(t, err)
+	named := r.MustRegister(New("registry test"))
+
+	_, err = r.Register(New("91001"))
+	assert.ErrorIs(t, err, ErrorDuplicateCode)
+	assert.ErrorContains(t, err, "registry_test.go:12")
+	assert.Panics(t, func() { r.MustRegister(Define(91000, CategoryInternal, "again")) })
+
+	e, ok := r.Lookup("91001")
+	assert.True(t, ok)
+	assert.Same(t, notFound, e)
+	_, ok = r.Lookup("91002")
+	assert.False(t, ok)
+
+	assert.Equal(t, []*Ecode{named, invalid, notFound}, r.All())
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	prev := defaultRegistry
+	defaultRegistry = NewRegistry()
+	t.Cleanup(func() { defaultRegistry = prev })
+
+	e := MustRegister(Define(91001, CategoryNotFound, "not found"))
+	_, err := Register(New("91001"))
+	assert.ErrorIs(t, err, ErrorDuplicateCode)
+	assert.ErrorContains(t, err, "registry_test.go:37, first registered at ")
+	assert.ErrorContains(t, err, "registry_test.go:36")
+	assert.Panics(t, func() { MustRegister(New("91001")) })
+
+	got, ok := Lookup("91001")
+	assert.True(t, ok)
+	assert.Same(t, e, got)
+	assert.Equal(t, []*Ecode{e}, All())
+}
@@ -1,16 +1,197 @@
 package perror
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"runtime"
 )
 
-func Wrap(err error) error {
+// Frame 是调用 Wrap 的位置, File 按 SetTrimMode 设置的方式裁剪
+type Frame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func (f Frame) String() string { return fmt.Sprintf("%s %s:%d", f.Func, f.File, f.Line) }
+
+// Field 是附加在错误上的键值对
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Error 是 Wrap 等函数返回的错误类型, 记录了调用位置以及可选的信息和键值对. 通过 errors.As 获取, 通过 Frames 读取位置,
+// 通过 %+v 打印多行的调用链; 没有附加信息时 Error() 只返回被包装错误的信息.
+type Error struct {
+	err    error
+	pc     uintptr
+	msg    string
+	fields []Field
+	stack  []uintptr // 完整的调用栈, 只在开启栈捕获且链上还没有调用栈时记录
+}
+
+// wrap 创建 *Error, skip 为 wrap 的调用方之上需要跳过的层数; stack 为 true 或全局开启了栈捕获时, 在链上还没有调用栈时记录调用栈
+func wrap(err error, skip int, msg string, fields []Field, stack bool) error {
+	if err == nil {
+		return nil
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(skip+3, pcs[:])
+	e := &Error{err: err, pc: pcs[0], msg: msg, fields: fields}
+
+	if (stack || stackEnabled.Load()) && !hasStack(err) {
+		e.stack = callers(skip + 4)
+	}
+
+	return e
+}
+
+// Wrap 包装 err 并记录调用 Wrap 的位置, err 为 nil 时返回 nil. 包装后的错误支持 errors.Is/As/Unwrap
+func Wrap(err error) error { return wrap(err, 0, "", nil, false) }
+
+// WrapSkip 与 Wrap 相同, 但记录的位置向上跳过 skip 层调用, 用于在辅助函数中包装错误时记录辅助函数的调用方.
+// skip 为 0 时与 Wrap 相同
+func WrapSkip(err error, skip int) error { return wrap(err, max(skip, 0), "", nil, false) }
+
+// Wrapf 与 Wrap 相同, 同时附加一条信息, Error() 返回 "信息: 被包装错误的信息"
+func Wrapf(err error, format string, args ...any) error {
 	if err == nil {
 		return nil
 	}
 
-	pc, f, l, _ := runtime.Caller(1)
+	return wrap(err, 0, fmt.Sprintf(format, args...), nil, false)
+}
+
+// WithFields 与 Wrap 相同, 同时附加键值对, 不影响 Error() 的内容. 通过 Fields 获取整条链上的键值对.
+// 入参:
+//
+//	kv: 键值对, 依次为 key, value, key, value...; key 不是 string 时通过 fmt.Sprint 转换, 缺少 value 的 key 作为 "!BADKEY" 的值
+func WithFields(err error, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, 0, "", toFields(kv), false)
+}
+
+func toFields(kv []any) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, Field{Key: "!BADKEY", Value: kv[i]})
+			break
+		}
+
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, Field{Key: key, Value: kv[i+1]})
+	}
 
-	return fmt.Errorf("<file: %s><line: %d><func: %s> | %w", f, l, runtime.FuncForPC(pc).Name(), err)
-}
\ No newline at end of file
+	return fields
+}
+
+// Fields 收集 err 的 Unwrap 链上所有 *Error 附加的键值对(包括 *RemoteError 从对端带来的键值对), 用于记录日志.
+// 同一个 key 出现多次时, 外层的值覆盖内层的值
+func Fields(err error) map[string]any {
+	fields := make(map[string]any)
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case *Error:
+			// 倒序遍历, 同一个 *Error 中后出现的值优先
+			for i := len(e.fields) - 1; i >= 0; i-- {
+				if f := e.fields[i]; !hasKey(fields, f.Key) {
+					fields[f.Key] = f.Value
+				}
+			}
+		case *RemoteError:
+			for k, v := range e.Fields {
+				if !hasKey(fields, k) {
+					fields[k] = v
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+func hasKey(m map[string]any, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (e *Error) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.err }
+
+// frame 把记录的 pc 解析为 Frame
+func (e *Error) frame() Frame {
+	f, _ := runtime.CallersFrames([]uintptr{e.pc}).Next()
+	return Frame{Func: f.Function, File: TrimPath(f.File), Line: f.Line}
+}
+
+// Frames 返回 e 以及 Unwrap 链上所有 *Error 记录的位置, 最内层(最早调用 Wrap) 的位置在前, 与调用栈的顺序一致.
+// 链上的 *RemoteError 带来的对端位置排在最前面
+func (e *Error) Frames() []Frame {
+	var frames []Frame
+	for err := error(e); err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case *Error:
+			frames = append(frames, e.frame())
+		case *RemoteError:
+			for i := len(e.Stack) - 1; i >= 0; i-- {
+				frames = append(frames, e.Stack[i])
+			}
+		}
+	}
+
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+
+	return frames
+}
+
+// Format 实现 fmt.Formatter: %s 和 %v 输出 Error(), %q 输出带引号的 Error(),
+// %+v 在 Error() 之后逐行输出 Frames() 中的每个位置, 链上记录了调用栈时再输出 "stack:" 和 Stack() 中的每一帧
+func (e *Error) Format(s fmt.State, verb rune) {
+	format(s, verb, e.Error(), e.Frames)
+
+	if verb == 'v' && s.Flag('+') {
+		if stack := e.Stack(); len(stack) != 0 {
+			io.WriteString(s, "\nstack:")
+			writeFrames(s, stack)
+		}
+	}
+}
+
+func format(s fmt.State, verb rune, msg string, frames func() []Frame) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, msg)
+		if s.Flag('+') {
+			writeFrames(s, frames())
+		}
+	case 's':
+		io.WriteString(s, msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", msg)
+	}
+}
+
+func writeFrames(w io.Writer, frames []Frame) {
+	for _, f := range frames {
+		fmt.Fprintf(w, "\n\t%s\n\t\t%s:%d", f.Func, f.File, f.Line)
+	}
+}
@@ -0,0 +1,87 @@
+package perror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readConfig() error { return Wrap(os.ErrNotExist) }
+
+func loadConfig() error { return Wrap(readConfig()) }
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(nil))
+
+	err := loadConfig()
+	assert.Equal(t, os.ErrNotExist.Error(), err.Error())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NotErrorIs(t, err, io.EOF)
+
+	var pe *Error
+	require.ErrorAs(t, err, &pe)
+	frames := pe.Frames()
+	require.Len(t, frames, 2)
+	assert.True(t, strings.HasSuffix(frames[0].Func, ".readConfig"))
+	assert.True(t, strings.HasSuffix(frames[1].Func, ".loadConfig"))
+	assert.True(t, strings.HasSuffix(frames[0].File, "error_test.go"))
+	assert.Equal(t, 15, frames[0].Line)
+	assert.Equal(t, 17, frames[1].Line)
+
+	inner := errors.Unwrap(err)
+	require.ErrorAs(t, inner, &pe)
+	assert.Len(t, pe.Frames(), 1)
+
+	// 中间经过其他包装时仍能找到所有位置
+	require.ErrorAs(t, Wrap(fmt.Errorf("load: %w", err)), &pe)
+	assert.Len(t, pe.Frames(), 3)
+}
+
+func TestFormat(t *testing.T) {
+	err := loadConfig()
+
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%q", err.Error()), fmt.Sprintf("%q", err))
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, err.Error(), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ".readConfig"))
+	assert.True(t, strings.HasSuffix(lines[2], "error_test.go:15"))
+	assert.True(t, strings.HasSuffix(lines[3], ".loadConfig"))
+	assert.True(t, strings.HasSuffix(lines[4], "error_test.go:17"))
+}
+
+func TestWrapfWithFields(t *testing.T) {
+	assert.Nil(t, Wrapf(nil, "load %s", "a"))
+	assert.Nil(t, WithFields(nil, "k", "v"))
+
+	err := WithFields(os.ErrNotExist, "path", "/etc/a.conf", "retry", 1)
+	assert.Equal(t, os.ErrNotExist.Error(), err.Error())
+
+	err = Wrapf(err, "load config %s", "a")
+	assert.Equal(t, "load config a: "+os.ErrNotExist.Error(), err.Error())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	err = WithFields(fmt.Errorf("init: %w", err), "retry", 2, "retry", 3, 42, "answer", "dangling")
+	assert.Equal(t, map[string]any{
+		"path":    "/etc/a.conf",
+		"retry":   3,
+		"42":      "answer",
+		"!BADKEY": "dangling",
+	}, Fields(err))
+
+	var pe *Error
+	require.ErrorAs(t, err, &pe)
+	assert.Len(t, pe.Frames(), 3)
+
+	assert.Empty(t, Fields(os.ErrNotExist))
+	assert.Empty(t, Fields(nil))
+}
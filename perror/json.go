@@ -0,0 +1,125 @@
+package perror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/puresnr/go/perror/ecode"
+)
+
+// Envelope 是错误在服务之间传递时使用的稳定格式, 字段只包含基本类型, 便于映射为 JSON 或 protobuf 消息
+type Envelope struct {
+	Code     string         `json:"code,omitempty"`     // 错误链上最外层的 ecode.Ecode 的 code, 没有时为空
+	Num      int            `json:"num,omitempty"`      // 该 Ecode 的数字错误码
+	Category string         `json:"category,omitempty"` // 该 Ecode 的类别
+	Message  string         `json:"message"`            // err.Error()
+	Fields   map[string]any `json:"fields,omitempty"`   // Fields(err), 值需要能被编码为 JSON
+	Stack    []Frame        `json:"stack,omitempty"`    // 可选的调用栈(见 WithStack) 或调用位置, 最内层在前
+}
+
+// ToEnvelope 把 err 转换为 Envelope, err 为 nil 时返回 nil.
+// 入参:
+//
+//	err: 待转换的错误
+//	withStack: 是否包含错误链上记录的调用栈, 没有调用栈时包含调用位置, 对外暴露的接口通常不应包含
+func ToEnvelope(err error, withStack bool) *Envelope {
+	if err == nil {
+		return nil
+	}
+
+	env := &Envelope{Message: err.Error()}
+	if code, ok := ecode.From(err); ok {
+		env.Code, env.Num, env.Category = code.Code(), code.Num(), string(code.Category())
+	}
+	if fields := Fields(err); len(fields) != 0 {
+		env.Fields = fields
+	}
+
+	if withStack {
+		var pe *Error
+		var re *RemoteError
+		if errors.As(err, &pe) {
+			// 记录了完整的调用栈时优先使用调用栈
+			if env.Stack = pe.Stack(); env.Stack == nil {
+				env.Stack = pe.Frames()
+			}
+		} else if errors.As(err, &re) {
+			env.Stack = re.Stack
+		}
+	}
+
+	return env
+}
+
+// Err 根据 Envelope 重建错误, env 为 nil 时返回 nil. 重建的错误是 *RemoteError:
+// code 已经通过 ecode.MustRegister 等注册到默认注册表时使用本地的 Ecode; 否则 code 是 num 的十进制形式时使用 ecode.Define 按 num 和 category 重建,
+// 其他情况使用 ecode.New(code). 因此 errors.Is 对本地的 Ecode 仍然有效.
+func (env *Envelope) Err() error { return env.ErrIn(nil) }
+
+// ErrIn 与 Err 相同, 但从 r 中查找本地的 Ecode, r 为 nil 时使用默认注册表
+func (env *Envelope) ErrIn(r *ecode.Registry) error {
+	if env == nil {
+		return nil
+	}
+
+	lookup := ecode.Lookup
+	if r != nil {
+		lookup = r.Lookup
+	}
+
+	re := &RemoteError{Message: env.Message, Fields: env.Fields, Stack: env.Stack}
+	if env.Code != "" {
+		var ok bool
+		if re.Code, ok = lookup(env.Code); !ok {
+			if env.Num != 0 && strconv.Itoa(env.Num) == env.Code {
+				re.Code = ecode.Define(env.Num, ecode.Category(env.Category), "")
+			} else {
+				re.Code = ecode.New(env.Code)
+			}
+		}
+	}
+
+	return re
+}
+
+// Marshal 把 err 编码为 JSON 格式的 Envelope, err 为 nil 时编码为 null
+func Marshal(err error, withStack bool) ([]byte, error) {
+	return json.Marshal(ToEnvelope(err, withStack))
+}
+
+// Unmarshal 解码 Marshal 的结果, 并把通过 Envelope.Err 重建的错误存入 err; data 为 null 时存入 nil.
+// 使用自己的 ecode.Registry 时, 解码为 Envelope 后调用 Envelope.ErrIn
+func Unmarshal(data []byte, err *error) error {
+	var env *Envelope
+	if e := json.Unmarshal(data, &env); e != nil {
+		return e
+	}
+	*err = env.Err()
+
+	return nil
+}
+
+// RemoteError 是由 Envelope 重建的错误, Error() 返回对端的错误信息, Unwrap 返回对应的 Ecode
+type RemoteError struct {
+	Code    *ecode.Ecode // 对端的错误码, 没有时为 nil
+	Message string
+	Fields  map[string]any
+	Stack   []Frame // 对端的调用位置, 最内层在前
+}
+
+func (e *RemoteError) Error() string { return e.Message }
+
+func (e *RemoteError) Unwrap() error {
+	if e.Code == nil {
+		return nil
+	}
+
+	return e.Code
+}
+
+// Format 与 *Error 的 Format 相同, %+v 逐行输出对端的调用位置
+func (e *RemoteError) Format(s fmt.State, verb rune) {
+	format(s, verb, e.Error(), func() []Frame { return e.Stack })
+}
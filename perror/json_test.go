@@ -0,0 +1,123 @@
+package perror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/puresnr/go/perror/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errorOrderNotFound = ecode.MustRegister(ecode.Define(94001, ecode.CategoryNotFound, "order not found"))
+
+func findOrder() error {
+	return WithFields(ecode.Wrap(errorOrderNotFound, io.EOF), "order", "A1")
+}
+
+func TestEnvelope(t *testing.T) {
+	assert.Nil(t, ToEnvelope(nil, true))
+
+	err := Wrapf(findOrder(), "get order")
+	env := ToEnvelope(err, true)
+	assert.Equal(t, "94001", env.Code)
+	assert.Equal(t, 94001, env.Num)
+	assert.Equal(t, "not_found", env.Category)
+	assert.Equal(t, err.Error(), env.Message)
+	assert.Equal(t, map[string]any{"order": "A1"}, env.Fields)
+	require.Len(t, env.Stack, 2)
+	assert.True(t, strings.HasSuffix(env.Stack[0].Func, ".findOrder"))
+
+	env = ToEnvelope(io.EOF, false)
+	assert.Equal(t, &Envelope{Message: "EOF"}, env)
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+
+	var decoded error = io.EOF
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Nil(t, decoded)
+	assert.Error(t, Unmarshal([]byte("{"), &decoded))
+
+	orig := WithFields(Wrapf(findOrder(), "get order"), "retry", 2)
+	data, err = Marshal(orig, true)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.ElementsMatch(t, []string{"code", "num", "category", "message", "fields", "stack"}, keys(raw))
+
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Equal(t, orig.Error(), decoded.Error())
+	assert.ErrorIs(t, decoded, errorOrderNotFound)
+	assert.NotErrorIs(t, decoded, io.EOF)
+
+	var re *RemoteError
+	require.ErrorAs(t, decoded, &re)
+	assert.Same(t, errorOrderNotFound, re.Code)
+	assert.Len(t, re.Stack, 3)
+
+	// 本地继续包装后, 键值对和调用位置包括对端的部分
+	local := WithFields(decoded, "retry", 3)
+	assert.Equal(t, map[string]any{"order": "A1", "retry": 3}, Fields(local))
+	var pe *Error
+	require.ErrorAs(t, local, &pe)
+	frames := pe.Frames()
+	require.Len(t, frames, 4)
+	assert.Equal(t, re.Stack, frames[:3])
+	assert.True(t, strings.HasSuffix(frames[3].Func, ".TestMarshal"))
+	assert.Len(t, strings.Split(fmt.Sprintf("%+v", decoded), "\n"), 7)
+
+	// 没有注册的 code 使用 ecode.New 重建, 按 code 比较仍然有效
+	data, err = Marshal(ecode.Wrap(ecode.New("remote-only"), errors.New("boom")), false)
+	require.NoError(t, err)
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.ErrorIs(t, decoded, ecode.New("remote-only"))
+	assert.Equal(t, "remote-only: boom", decoded.Error())
+
+	// 没有注册的数字 code 按 num 和 category 重建
+	data, err = Marshal(Wrap(ecode.Define(94404, ecode.CategoryNotFound, "gone")), false)
+	require.NoError(t, err)
+	require.NoError(t, Unmarshal(data, &decoded))
+	require.ErrorAs(t, decoded, &re)
+	assert.ErrorIs(t, decoded, ecode.New("94404"))
+	assert.Equal(t, 94404, re.Code.Num())
+	assert.Equal(t, ecode.CategoryNotFound, re.Code.Category())
+	assert.Equal(t, 404, re.Code.HTTPStatus())
+	assert.Equal(t, "94404: gone", decoded.Error())
+
+	// 使用自己的注册表时通过 ErrIn 查找本地的 Ecode
+	reg := ecode.NewRegistry()
+	busy := reg.MustRegister(ecode.Define(94501, ecode.CategoryUnavailable, "busy"))
+	var env Envelope
+	data, err = Marshal(Wrap(busy), false)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &env))
+	require.ErrorAs(t, env.ErrIn(reg), &re)
+	assert.Same(t, busy, re.Code)
+	require.ErrorAs(t, env.Err(), &re)
+	assert.NotSame(t, busy, re.Code)
+	assert.Nil(t, (*Envelope)(nil).ErrIn(reg))
+
+	data, err = Marshal(io.EOF, false)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message":"EOF"}`, string(data))
+	require.NoError(t, Unmarshal(data, &decoded))
+	assert.Nil(t, errors.Unwrap(decoded))
+}
+
+func keys(m map[string]any) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+
+	return ks
+}
@@ -0,0 +1,137 @@
+package perror
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MultiError 收集多个错误, 零值可用, 可以并发使用. 相同类型且 Error() 相同的错误只保留第一个;
+// errors.Is/As 会检查所有成员. 典型用法:
+//
+//	var me perror.MultiError
+//	for _, f := range fields {
+//		me.Append(validate(f))
+//	}
+//	return me.Err()
+type MultiError struct {
+	mu   sync.Mutex
+	errs []error
+	seen map[string]struct{}
+}
+
+// Append 把 errs 中非 nil 的错误加入 me, 成员是 *MultiError 时加入其所有成员(nil 的 *MultiError 被忽略), 重复的错误被忽略
+func (me *MultiError) Append(errs ...error) {
+	// 先在不持有 mu 的情况下展开 *MultiError, 避免两个 MultiError 互相 Append 时死锁
+	flat := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if m, ok := err.(*MultiError); ok {
+			if m != nil && m != me {
+				flat = append(flat, m.Errors()...)
+			}
+		} else if err != nil {
+			flat = append(flat, err)
+		}
+	}
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	for _, err := range flat {
+		key := fmt.Sprintf("%T\x00%s", err, err.Error())
+		if _, ok := me.seen[key]; ok {
+			continue
+		}
+		if me.seen == nil {
+			me.seen = make(map[string]struct{})
+		}
+		me.seen[key] = struct{}{}
+		me.errs = append(me.errs, err)
+	}
+}
+
+// Errors 返回所有错误的副本, 按加入的顺序排列
+func (me *MultiError) Errors() []error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	return append([]error(nil), me.errs...)
+}
+
+// Len 返回错误的数量
+func (me *MultiError) Len() int {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	return len(me.errs)
+}
+
+// Err 没有错误时返回 nil, 否则返回当前所有错误的快照(*MultiError), 之后的 Append 不影响返回值
+func (me *MultiError) Err() error {
+	errs := me.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	snap := &MultiError{}
+	snap.Append(errs...)
+
+	return snap
+}
+
+// Error 只有一个错误时返回该错误的信息, 否则返回 "n errors: a; b; c"
+func (me *MultiError) Error() string {
+	errs := me.Errors()
+	switch len(errs) {
+	case 0:
+		return "no errors"
+	case 1:
+		return errs[0].Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strconv.Itoa(len(errs)))
+	sb.WriteString(" errors: ")
+	for i, err := range errs {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+
+	return sb.String()
+}
+
+// Unwrap 返回所有错误, 使 errors.Is/As 检查每个成员
+func (me *MultiError) Unwrap() []error { return me.Errors() }
+
+// Format 实现 fmt.Formatter: %+v 时逐个以 %+v 输出每个错误, 其他与 *Error 相同
+func (me *MultiError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		format(s, verb, me.Error(), nil)
+		return
+	}
+
+	errs := me.Errors()
+	fmt.Fprintf(s, "%d errors:", len(errs))
+	for i, err := range errs {
+		fmt.Fprintf(s, "\n[%d] ", i)
+		io.WriteString(s, strings.ReplaceAll(fmt.Sprintf("%+v", err), "\n", "\n    "))
+	}
+}
+
+// Append 把 errs 合并到 err 中并返回合并后的错误, 不修改 err 本身:
+// 所有错误都为 nil 时返回 nil, 否则返回新的 *MultiError. err 是 *MultiError 时展开其成员.
+func Append(err error, errs ...error) error {
+	var me MultiError
+	me.Append(err)
+	me.Append(errs...)
+
+	if me.Len() == 0 {
+		return nil
+	}
+
+	return &me
+}
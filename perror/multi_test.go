@@ -0,0 +1,81 @@
+package perror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/puresnr/go/perror/ecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiError(t *testing.T) {
+	var me MultiError
+	assert.Nil(t, me.Err())
+	me.Append()
+	me.Append(nil, nil)
+	assert.Equal(t, 0, me.Len())
+	assert.Nil(t, me.Err())
+
+	code := ecode.New("invalid")
+	me.Append(io.EOF, nil, anotherError{errors.New("EOF")}, io.EOF)
+	assert.Equal(t, 2, me.Len(), "io.EOF 重复, 与其类型不同的同名错误保留")
+	me.Append(Wrap(os.ErrNotExist), code)
+
+	err := me.Err()
+	me.Append(errors.New("later"))
+	require.Error(t, err)
+	assert.Equal(t, `4 errors: EOF; EOF; file does not exist; invalid`, err.Error())
+	assert.ErrorIs(t, err, io.EOF)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorIs(t, err, ecode.New("invalid"))
+	assert.NotErrorIs(t, err, io.ErrUnexpectedEOF)
+	var pe *Error
+	assert.ErrorAs(t, err, &pe)
+	var ec *ecode.Ecode
+	assert.ErrorAs(t, err, &ec)
+
+	// 合并另一个 MultiError 时展开其成员, 忽略重复
+	var other MultiError
+	other.Append(&me, io.ErrClosedPipe)
+	other.Append(&other)
+	assert.Equal(t, 6, other.Len())
+
+	single := Append(nil, nil, io.EOF)
+	assert.Equal(t, "EOF", single.Error())
+	assert.Nil(t, Append(nil))
+	assert.Nil(t, Append(nil, nil))
+	assert.Nil(t, Append(nil, (*MultiError)(nil)))
+	merged := Append(single, io.ErrClosedPipe, single)
+	assert.Equal(t, "2 errors: EOF; io: read/write on closed pipe", merged.Error())
+	assert.Equal(t, "EOF", single.Error(), "Append 不修改原来的错误")
+
+	lines := strings.Split(fmt.Sprintf("%+v", me.Err()), "\n")
+	assert.Equal(t, "5 errors:", lines[0])
+	assert.Equal(t, "[0] EOF", lines[1])
+	assert.Equal(t, "[2] file does not exist", lines[3])
+	assert.True(t, strings.HasPrefix(lines[4], "    \t"))
+	assert.Equal(t, me.Error(), fmt.Sprintf("%v", &me))
+}
+
+func TestMultiErrorConcurrent(t *testing.T) {
+	var a, b MultiError
+	a.Append(io.EOF)
+	b.Append(io.ErrClosedPipe)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(3)
+		go func() { defer wg.Done(); a.Append(&b, fmt.Errorf("a%d", i%10)) }()
+		go func() { defer wg.Done(); b.Append(&a) }()
+		go func() { defer wg.Done(); _ = a.Error() }()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 12, a.Len())
+}
@@ -0,0 +1,64 @@
+package perror
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// maxStackDepth 是记录调用栈的最大深度
+const maxStackDepth = 64
+
+var stackEnabled atomic.Bool
+
+// EnableStack 开启或关闭全局的调用栈捕获, 返回之前的开关状态. 默认关闭.
+// 开启后, Wrap/WrapSkip/Wrapf/WithFields 在错误链上还没有调用栈时记录完整的调用栈, 外层的包装不会重复记录;
+// 关闭时只有 WithStack 会记录调用栈.
+func EnableStack(on bool) bool { return stackEnabled.Swap(on) }
+
+// StackEnabled 返回当前是否开启了全局的调用栈捕获
+func StackEnabled() bool { return stackEnabled.Load() }
+
+// WithStack 与 Wrap 相同, 但无论是否开启了全局的调用栈捕获, 都在错误链上还没有调用栈时记录完整的调用栈, err 为 nil 时返回 nil
+func WithStack(err error) error { return wrap(err, 0, "", nil, true) }
+
+// Stack 返回 e 的 Unwrap 链上记录的调用栈, 调用栈在最内层开启捕获的包装处记录, 最深的调用在前; 没有记录时返回 nil
+func (e *Error) Stack() []Frame {
+	for err := error(e); err != nil; err = errors.Unwrap(err) {
+		if pe, ok := err.(*Error); ok && pe.stack != nil {
+			return resolve(pe.stack)
+		}
+	}
+
+	return nil
+}
+
+func hasStack(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if pe, ok := err.(*Error); ok && pe.stack != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callers 记录调用栈, skip 的含义与 runtime.Callers 相同
+func callers(skip int) []uintptr {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
+func resolve(pcs []uintptr) []Frame {
+	frames := make([]Frame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		frames = append(frames, Frame{Func: f.Function, File: TrimPath(f.File), Line: f.Line})
+		if !more {
+			return frames
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package perror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryRow() error { return WithStack(io.ErrUnexpectedEOF) }
+
+func queryUser() error { return Wrapf(queryRow(), "query user") }
+
+func TestWithStack(t *testing.T) {
+	assert.Nil(t, WithStack(nil))
+
+	err := Wrap(queryUser())
+	var pe *Error
+	require.ErrorAs(t, err, &pe)
+
+	stack := pe.Stack()
+	require.GreaterOrEqual(t, len(stack), 3)
+	assert.True(t, strings.HasSuffix(stack[0].Func, ".queryRow"))
+	assert.Equal(t, 14, stack[0].Line)
+	assert.True(t, strings.HasSuffix(stack[1].Func, ".queryUser"))
+	assert.True(t, strings.HasSuffix(stack[2].Func, ".TestWithStack"))
+
+	// 外层不重复记录
+	n := 0
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if p, ok := e.(*Error); ok && p.stack != nil {
+			n++
+		}
+	}
+	assert.Equal(t, 1, n)
+	require.ErrorAs(t, WithStack(err), &pe)
+	assert.Nil(t, pe.stack)
+	assert.Equal(t, stack, pe.Stack())
+
+	out := fmt.Sprintf("%+v", err)
+	head, tail, ok := strings.Cut(out, "\nstack:")
+	require.True(t, ok)
+	assert.Len(t, strings.Split(head, "\n"), 1+2*3)
+	assert.Equal(t, 2*len(stack), strings.Count(tail, "\n"))
+
+	plain := Wrap(io.EOF)
+	require.ErrorAs(t, plain, &pe)
+	assert.Nil(t, pe.Stack())
+	assert.NotContains(t, fmt.Sprintf("%+v", plain), "stack:")
+
+	env := ToEnvelope(err, true)
+	assert.Equal(t, stack, env.Stack)
+}
+
+func TestEnableStack(t *testing.T) {
+	prev := EnableStack(true)
+	t.Cleanup(func() { EnableStack(prev) })
+	assert.True(t, StackEnabled())
+
+	var pe *Error
+	require.ErrorAs(t, Wrap(WithFields(io.EOF, "k", "v")), &pe)
+	stack := pe.Stack()
+	require.NotEmpty(t, stack)
+	assert.True(t, strings.HasSuffix(stack[0].Func, ".TestEnableStack"))
+	assert.Nil(t, pe.stack, "只在最内层记录")
+
+	assert.True(t, EnableStack(false))
+	require.ErrorAs(t, Wrap(io.EOF), &pe)
+	assert.Nil(t, pe.Stack())
+}
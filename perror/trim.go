@@ -0,0 +1,18 @@
+package perror
+
+import "github.com/puresnr/go/trimpath"
+
+// TrimMode 决定 Frame.File 以及 gosafe 打印的调用栈中文件路径的形式, 默认 TrimNone. 实际的设置保存在 trimpath 中
+type TrimMode = trimpath.Mode
+
+const (
+	TrimNone       = trimpath.None       // 保留完整的构建路径
+	TrimModuleRoot = trimpath.ModuleRoot // 主模块内的文件使用相对于模块根目录的路径, 其他文件按 TrimGOPATH 处理
+	TrimGOPATH     = trimpath.GOPATH     // 去掉 GOPATH/src 和 GOPATH/pkg/mod 前缀, 即从 import path 开始
+)
+
+// SetTrimMode 设置文件路径的裁剪方式, 返回之前的方式, 见 trimpath.SetMode
+func SetTrimMode(mode TrimMode) TrimMode { return trimpath.SetModeSkip(mode, 1) }
+
+// TrimPath 按 SetTrimMode 设置的方式裁剪 file, 见 trimpath.Path
+func TrimPath(file string) string { return trimpath.Path(file) }
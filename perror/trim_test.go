@@ -0,0 +1,56 @@
+package perror
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrapHelper 是一个辅助函数, 记录的位置应当是它的调用方
+func wrapHelper(err error) error { return WrapSkip(err, 1) }
+
+func TestWrapSkip(t *testing.T) {
+	assert.Nil(t, WrapSkip(nil, 1))
+
+	var pe *Error
+	require.ErrorAs(t, wrapHelper(os.ErrClosed), &pe)
+	assert.Equal(t, 20, pe.Frames()[0].Line)
+	assert.Contains(t, pe.Frames()[0].Func, "TestWrapSkip")
+
+	require.ErrorAs(t, WrapSkip(os.ErrClosed, -1), &pe)
+	assert.Contains(t, pe.Frames()[0].Func, "TestWrapSkip")
+}
+
+func TestTrimPath(t *testing.T) {
+	prev := SetTrimMode(TrimNone)
+	t.Cleanup(func() { SetTrimMode(prev) })
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	file := filepath.ToSlash(filepath.Join(wd, "trim_test.go"))
+	root := filepath.ToSlash(filepath.Dir(wd))
+
+	var pe *Error
+	require.True(t, errors.As(Wrap(os.ErrClosed), &pe))
+	assert.Equal(t, file, pe.Frames()[0].File)
+	assert.Equal(t, file, TrimPath(file))
+
+	assert.Equal(t, TrimNone, SetTrimMode(TrimModuleRoot))
+	assert.Equal(t, "perror/trim_test.go", TrimPath(file))
+	assert.Equal(t, "perror/trim_test.go", pe.Frames()[0].File)
+	assert.Equal(t, "/elsewhere/a.go", TrimPath("/elsewhere/a.go"))
+
+	t.Setenv("GOPATH", "/gopath1"+string(filepath.ListSeparator)+"/gopath2/")
+	assert.Equal(t, TrimModuleRoot, SetTrimMode(TrimGOPATH))
+	assert.Equal(t, file, TrimPath(file))
+	assert.Equal(t, "github.com/a/b@v1.0.0/c.go", TrimPath("/gopath2/pkg/mod/github.com/a/b@v1.0.0/c.go"))
+	assert.Equal(t, "github.com/a/b/c.go", TrimPath("/gopath1/src/github.com/a/b/c.go"))
+
+	SetTrimMode(TrimModuleRoot)
+	assert.Equal(t, "github.com/a/b/c.go", TrimPath("/gopath1/src/github.com/a/b/c.go"))
+	assert.Equal(t, "go.mod", TrimPath(root+"/go.mod"))
+}
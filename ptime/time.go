@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -125,3 +126,27 @@ func YearDay(md string, isleap bool) (uint, error) {
 
 	return monthSumDays[isleap][im-1] + uint(id), nil
 }
+
+var locations sync.Map // map[string]*time.Location
+
+// LoadLocation 与 time.LoadLocation 相同, 但会缓存加载结果, 避免每次都读取时区数据库.
+//
+// 参数:
+// - name: string 类型，表示时区名称，比如 "Asia/Shanghai"，"" 和 "UTC" 表示 UTC，"Local" 表示本地时区。
+//
+// 返回值:
+// - *time.Location 类型，表示对应的时区。
+// - error 类型，如果时区名称无效，则返回错误信息。
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+
+	return loc, nil
+}
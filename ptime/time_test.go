@@ -183,3 +183,16 @@ func TestCalcDiffDay(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	if cached, _ := LoadLocation("Asia/Shanghai"); cached != loc {
+		t.Errorf("LoadLocation() should return the cached location")
+	}
+	if _, err := LoadLocation("Nowhere/City"); err == nil {
+		t.Errorf("LoadLocation() should fail for unknown zone")
+	}
+}
@@ -0,0 +1,196 @@
+// Package retry 提供通用的重试执行器, 支持固定间隔、指数退避和去相关抖动(decorrelated jitter) 三种退避策略,
+// 以及按最大次数、最大耗时和错误类型控制是否继续重试. 调用方式: retry.Do(ctx, f, retry.Policy{...})
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff 决定两次尝试之间的等待时间
+type Backoff interface {
+	// Next 返回第 attempt 次失败之后需要等待的时间, attempt 从 1 开始, prev 为上一次的等待时间, 第一次为 0
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// Constant 是固定间隔的退避策略
+type Constant time.Duration
+
+func (c Constant) Next(int, time.Duration) time.Duration { return time.Duration(c) }
+
+// Exponential 是指数退避策略: 等待时间为 Initial * Multiplier^(attempt-1), 不超过 Max,
+// 并在此基础上随机浮动 ±Jitter 的比例
+type Exponential struct {
+	Initial    time.Duration
+	Max        time.Duration // 小于等于 0 时不限制
+	Multiplier float64       // 小于等于 1 时按 2 处理
+	Jitter     float64       // 取值 0~1, 为 0 时不浮动
+}
+
+func (e Exponential) Next(attempt int, _ time.Duration) time.Duration {
+	m := e.Multiplier
+	if m <= 1 {
+		m = 2
+	}
+
+	d := float64(e.Initial) * math.Pow(m, float64(attempt-1))
+	if e.Max > 0 && d > float64(e.Max) {
+		d = float64(e.Max)
+	}
+	if e.Jitter > 0 {
+		d += d * e.Jitter * (rand.Float64()*2 - 1)
+	}
+	if d > float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(d)
+}
+
+// DecorrelatedJitter 是去相关抖动的退避策略: 等待时间在 [Base, prev*3] 之间随机, 不超过 Max.
+// 相比指数退避, 大量客户端同时重试时能更好的错开.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	Max  time.Duration // 小于等于 0 时不限制
+}
+
+func (d DecorrelatedJitter) Next(_ int, prev time.Duration) time.Duration {
+	upper := max(prev*3, d.Base)
+	if prev > math.MaxInt64/3 {
+		upper = time.Duration(math.MaxInt64)
+	}
+
+	next := d.Base
+	if upper > d.Base {
+		next += rand.N(upper - d.Base)
+	}
+	if d.Max > 0 {
+		next = min(next, d.Max)
+	}
+
+	return next
+}
+
+// Policy 描述重试的方式
+type Policy struct {
+	Backoff     Backoff       // 为 nil 时不等待
+	MaxAttempts int           // 最多尝试的次数(包括第一次), 小于等于 0 时不限制
+	MaxElapsed  time.Duration // 从第一次尝试开始的最长耗时, 预计等待后会超出时不再重试, 小于等于 0 时不限制
+	// Retryable 判断错误是否值得重试, 为 nil 时除 Permanent 之外的所有错误都重试
+	Retryable func(error) bool
+	// OnRetry 在每次等待之前调用, 可以用于记录日志
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultPolicy 最多尝试 3 次, 使用从 100ms 开始、最长 2s 的指数退避
+var DefaultPolicy = Policy{
+	Backoff:     Exponential{Initial: 100 * time.Millisecond, Max: 2 * time.Second, Jitter: 0.2},
+	MaxAttempts: 3,
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装一个错误, 表示不应该再重试, Do 会直接返回原始的 err. err 为 nil 时返回 nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// RetryOn 返回一个 Retryable, 只有通过 errors.Is 匹配 targets 中任意一个的错误才重试.
+// 由于 ecode.Ecode 实现了按 code 比较的 Is 方法, targets 可以直接使用 ecode.New 创建的错误码.
+func RetryOn(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, t := range targets {
+			if errors.Is(err, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NotRetryOn 返回一个 Retryable, 通过 errors.Is 匹配 targets 中任意一个的错误不重试, 其他错误都重试
+func NotRetryOn(targets ...error) func(error) bool {
+	on := RetryOn(targets...)
+	return func(err error) bool { return !on(err) }
+}
+
+// Do 执行 f, 失败时按照 p 重试, 直到成功、错误不可重试、次数或耗时用尽, 或 ctx 结束.
+// 返回值:
+//
+//	成功时返回 nil; 不再重试时返回最后一次的错误(Permanent 包装的错误会被还原);
+//	等待期间 ctx 结束时返回同时包装了 ctx.Err() 和最后一次错误的错误, 两者都可以通过 errors.Is 判断.
+func Do(ctx context.Context, f func() error, p Policy) error {
+	_, err := DoValue(ctx, func() (struct{}, error) { return struct{}{}, f() }, p)
+	return err
+}
+
+// DoValue 与 Do 相同, 用于有返回值的函数, 成功时返回 f 的返回值
+func DoValue[T any](ctx context.Context, f func() (T, error), p Policy) (T, error) {
+	start := time.Now()
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+
+		v, err := f()
+		if err == nil {
+			return v, nil
+		}
+
+		var pe *permanentError
+		if errors.As(err, &pe) {
+			return v, pe.err
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return v, err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return v, err
+		}
+
+		if p.Backoff != nil {
+			delay = max(p.Backoff.Next(attempt, delay), 0)
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return v, err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		if werr := sleep(ctx, delay); werr != nil {
+			return v, fmt.Errorf("%w: last error: %w", werr, err)
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/perror"
+	"github.com/puresnr/go/perror/ecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Constant(time.Second).Next(3, 0))
+
+	e := Exponential{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, e.Next(1, 0))
+	assert.Equal(t, 40*time.Millisecond, e.Next(3, 0))
+	assert.Equal(t, 50*time.Millisecond, e.Next(10, 0))
+	assert.Equal(t, 50*time.Millisecond, e.Next(1000, 0))
+
+	d := DecorrelatedJitter{Base: 10 * time.Millisecond, Max: 100 * time.Millisecond}
+	prev := time.Duration(0)
+	for i := 1; i < 50; i++ {
+		next := d.Next(i, prev)
+		assert.GreaterOrEqual(t, next, 10*time.Millisecond)
+		assert.LessOrEqual(t, next, max(prev*3, 10*time.Millisecond))
+		assert.LessOrEqual(t, next, 100*time.Millisecond)
+		prev = next
+	}
+}
+
+func TestDo(t *testing.T) {
+	fail := errors.New("fail")
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		n := 0
+		v, err := DoValue(context.Background(), func() (int, error) {
+			if n++; n < 3 {
+				return 0, fail
+			}
+			return n, nil
+		}, Policy{Backoff: Constant(time.Millisecond), MaxAttempts: 5})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, v)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		n := 0
+		var retries []int
+		err := Do(context.Background(), func() error { n++; return fail }, Policy{
+			MaxAttempts: 3,
+			OnRetry:     func(attempt int, _ error, _ time.Duration) { retries = append(retries, attempt) },
+		})
+		assert.Equal(t, fail, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []int{1, 2}, retries)
+	})
+
+	t.Run("max elapsed", func(t *testing.T) {
+		n := 0
+		err := Do(context.Background(), func() error { n++; return fail }, Policy{
+			Backoff:    Constant(20 * time.Millisecond),
+			MaxElapsed: 50 * time.Millisecond,
+		})
+		assert.Equal(t, fail, err)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("retryable ecode through wrap", func(t *testing.T) {
+		busy, invalid := ecode.New("busy"), ecode.New("invalid")
+		p := Policy{MaxAttempts: 5, Retryable: RetryOn(ecode.New("busy"))}
+
+		n := 0
+		err := Do(context.Background(), func() error { n++; return perror.Wrap(busy) }, p)
+		assert.ErrorIs(t, err, busy)
+		assert.Equal(t, 5, n)
+
+		n = 0
+		err = Do(context.Background(), func() error { n++; return perror.Wrap(invalid) }, p)
+		assert.ErrorIs(t, err, invalid)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		n := 0
+		err := Do(context.Background(), func() error { n++; return Permanent(fail) }, Policy{})
+		assert.Equal(t, fail, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("ctx cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := Do(ctx, func() error { return fail }, Policy{Backoff: Constant(time.Hour)})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, err, fail)
+	})
+}
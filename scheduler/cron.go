@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/puresnr/go/ptime"
+)
+
+var ErrorInvalidCron = errors.New("invalid cron expression")
+
+// Schedule 决定任务的执行时间
+type Schedule interface {
+	// Next 返回晚于 t 的下一次执行时间, 不存在时返回零值
+	Next(t time.Time) time.Time
+}
+
+// Every 是固定间隔的 Schedule, 间隔从上一次执行结束开始计算
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+
+	return t.Add(time.Duration(e))
+}
+
+// CronSchedule 是由 cron 表达式描述的 Schedule, 通过 ParseCron 创建
+type CronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// dom 和 dow 都不是 "*" 时, 满足其中一个即可, 与标准 cron 一致
+	domAny, dowAny bool
+	loc            *time.Location
+}
+
+type cronField struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondField = cronField{min: 0, max: 59}
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// dow 中 0 和 7 都表示星期日
+	dowField = cronField{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron 解析 cron 表达式, 时区默认为 time.Local.
+//
+// 支持的格式:
+//   - 5 个字段: 分 时 日 月 周
+//   - 6 个字段: 秒 分 时 日 月 周
+//   - 预定义: @yearly(@annually), @monthly, @weekly, @daily(@midnight), @hourly
+//
+// 每个字段支持 "*", "a", "a-b", "*/n", "a-b/n", "a/n" 以及用逗号分隔的组合, 月和周支持英文缩写(jan, mon 等),
+// 周中 0 和 7 都表示星期日. 表达式可以以 "TZ=时区名 " 或 "CRON_TZ=时区名 " 开头指定时区, 时区通过 ptime.LoadLocation 加载.
+func ParseCron(expr string) (*CronSchedule, error) { return ParseCronIn(expr, time.Local) }
+
+// ParseCronIn 与 ParseCron 相同, 但表达式没有指定时区时使用 loc
+func ParseCronIn(expr string, loc *time.Location) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		tz, rest, _ := strings.Cut(expr, " ")
+		_, name, _ := strings.Cut(tz, "=")
+
+		var err error
+		if loc, err = ptime.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrorInvalidCron, err)
+		}
+		expr = strings.TrimSpace(rest)
+	}
+
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: expected 5 or 6 fields, got %d in %q", ErrorInvalidCron, len(fields), expr)
+	}
+
+	if loc == nil {
+		loc = time.Local
+	}
+	c := &CronSchedule{loc: loc, domAny: fields[3] == "*" || fields[3] == "?", dowAny: fields[5] == "*" || fields[5] == "?"}
+
+	var err error
+	for i, p := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&c.second, secondField}, {&c.minute, minuteField}, {&c.hour, hourField},
+		{&c.dom, domField}, {&c.month, monthField}, {&c.dow, dowField},
+	} {
+		if *p.bits, err = parseField(fields[i], p.field); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	return c, nil
+}
+
+func parseField(s string, f cronField) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		var lo, hi uint
+		switch rng {
+		case "*", "?":
+			lo, hi = f.min, f.max
+		default:
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if lo, err = parseValue(loStr, f); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(hiStr, f); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "a/n" 表示从 a 开始到最大值, 每 n 个取一个
+				hi = f.max
+			}
+		}
+
+		step := uint64(1)
+		if hasStep {
+			var err error
+			if step, err = strconv.ParseUint(stepStr, 10, 8); err != nil || step == 0 {
+				return 0, fmt.Errorf("%w: invalid step in %q", ErrorInvalidCron, part)
+			}
+		}
+
+		if lo > hi {
+			return 0, fmt.Errorf("%w: invalid range %q", ErrorInvalidCron, part)
+		}
+		for v := uint64(lo); v <= uint64(hi); v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+func parseValue(s string, f cronField) (uint, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf("%w: value %q out of range [%d, %d]", ErrorInvalidCron, s, f.min, f.max)
+	}
+
+	return uint(v), nil
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domAny || c.dowAny {
+		return domOK && dowOK
+	}
+
+	return domOK || dowOK
+}
+
+// Location 返回计算执行时间时使用的时区
+func (c *CronSchedule) Location() *time.Location { return c.loc }
+
+// Next 返回晚于 t 的下一次满足表达式的时间, 结果使用 t 的时区; 5 年内都不满足时(比如 2 月 30 日) 返回零值
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(c.loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		y, mo, d := t.Date()
+		h, mi, s := t.Clock()
+
+		switch {
+		case c.month&(1<<uint(mo)) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, c.loc)
+		case c.hour&(1<<uint(h)) == 0:
+			t = time.Date(y, mo, d, h+1, 0, 0, 0, c.loc)
+		case c.minute&(1<<uint(mi)) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case c.second&(1<<uint(s)) == 0:
+			// 直接跳到本分钟内下一个满足的秒, 没有则跳到下一分钟
+			if next := c.second >> uint(s) >> 1; next != 0 && s+1+bits.TrailingZeros64(next) < 60 {
+				t = t.Add(time.Duration(1+bits.TrailingZeros64(next)) * time.Second)
+			} else {
+				t = t.Truncate(time.Minute).Add(time.Minute)
+			}
+		default:
+			return t.In(origLoc)
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "TZ=Nowhere/City * * * * *"} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrorInvalidCron, expr)
+	}
+
+	for _, expr := range []string{"@daily", "0 9 * * mon-fri", "*/15 0-6/2 1,15 jan,jul sun", "30 */5 * * * *", "CRON_TZ=UTC 0 0 * * 7"} {
+		_, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	base := time.Date(2024, 2, 28, 10, 17, 30, 0, time.UTC) // Wednesday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 2, 28, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * * *", time.Date(2024, 2, 28, 10, 17, 45, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 2, 28, 11, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, // dom or dow
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"TZ=Asia/Shanghai 0 9 * * *", time.Date(2024, 2, 29, 9, 0, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCronIn(tt.expr, time.UTC)
+		require.NoError(t, err, tt.expr)
+		assert.True(t, tt.want.Equal(c.Next(base)), "%s: want %v, got %v", tt.expr, tt.want, c.Next(base))
+	}
+
+	never, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(base).IsZero())
+}
@@ -0,0 +1,285 @@
+// Package scheduler 提供周期任务调度: 任务可以按固定间隔或 cron 表达式执行, 同一个任务的多次执行不会重叠,
+// 每次执行都通过 gosafe 捕获 panic. 调用方式:
+//
+//	s := scheduler.New()
+//	s.Cron("report", "TZ=Asia/Shanghai 0 9 * * mon-fri", job)
+//	s.Start(ctx)
+//	defer s.Stop()
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+)
+
+var (
+	ErrorDuplicateJob = errors.New("duplicate job name")
+	ErrorNoNextRun    = errors.New("schedule has no next run")
+	ErrorInvalidJob   = errors.New("invalid job")
+)
+
+// Job 是被调度的任务, ctx 会在任务被移除或调度器停止时被取消
+type Job func(ctx context.Context) error
+
+type options struct {
+	loc     *time.Location
+	onError func(name string, err error)
+}
+
+// Option 用于配置 Scheduler
+type Option func(*options)
+
+// WithLocation 设置没有指定时区的 cron 表达式使用的时区, 默认 time.Local
+func WithLocation(loc *time.Location) Option { return func(o *options) { o.loc = loc } }
+
+// WithErrorHandler 设置任务返回错误或 panic 时的回调, panic 以 *gosafe.PanicError 的形式传入
+func WithErrorHandler(f func(name string, err error)) Option {
+	return func(o *options) { o.onError = f }
+}
+
+type jobOptions struct {
+	jitter    time.Duration
+	immediate bool
+}
+
+// JobOption 用于配置单个任务
+type JobOption func(*jobOptions)
+
+// WithJitter 设置每次执行前额外随机等待 [0, d) 的时间, 用于错开多个实例同时执行
+func WithJitter(d time.Duration) JobOption { return func(o *jobOptions) { o.jitter = d } }
+
+// WithImmediate 设置任务在加入(或调度器启动) 时先立即执行一次
+func WithImmediate() JobOption { return func(o *jobOptions) { o.immediate = true } }
+
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+	opts     jobOptions
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu      sync.Mutex
+	next    time.Time
+	running bool
+}
+
+// JobInfo 描述一个任务的当前状态
+type JobInfo struct {
+	Name    string
+	Next    time.Time // 下一次计划执行的时间, 不包括 jitter; 正在执行时为零值
+	Running bool
+}
+
+// Scheduler 是周期任务调度器, 必须通过 New 创建, 可以并发使用.
+// 任务可以在 Start 之前或之后加入, Start 之前加入的任务在 Start 时开始调度.
+type Scheduler struct {
+	opts options
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	entries map[string]*entry
+}
+
+// New 创建一个调度器
+func New(opts ...Option) *Scheduler {
+	o := options{loc: time.Local}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Scheduler{opts: o, entries: make(map[string]*entry)}
+}
+
+// Every 加入一个按固定间隔执行的任务, 间隔从上一次执行结束开始计算; interval 不大于 0 时返回 ErrorInvalidJob
+func (s *Scheduler) Every(name string, interval time.Duration, job Job, opts ...JobOption) error {
+	return s.Add(name, Every(interval), job, opts...)
+}
+
+// Cron 加入一个按 cron 表达式执行的任务, 表达式格式见 ParseCron
+func (s *Scheduler) Cron(name, expr string, job Job, opts ...JobOption) error {
+	c, err := ParseCronIn(expr, s.opts.loc)
+	if err != nil {
+		return err
+	}
+
+	return s.Add(name, c, job, opts...)
+}
+
+// Add 加入一个按 schedule 执行的任务, name 不能重复. job 或 schedule 为 nil, 或 schedule 是不大于 0 的 Every 时返回 ErrorInvalidJob
+func (s *Scheduler) Add(name string, schedule Schedule, job Job, opts ...JobOption) error {
+	if ev, ok := schedule.(Every); job == nil || schedule == nil || (ok && ev <= 0) {
+		return ErrorInvalidJob
+	}
+
+	e := &entry{name: name, schedule: schedule, job: job}
+	for _, opt := range opts {
+		opt(&e.opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[name]; ok {
+		return ErrorDuplicateJob
+	}
+	s.entries[name] = e
+
+	if s.ctx != nil {
+		s.run(e)
+	}
+
+	return nil
+}
+
+// Remove 移除任务并取消其 ctx, 不等待正在进行的执行结束, 返回任务是否存在
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	delete(s.entries, name)
+	s.mu.Unlock()
+
+	if ok && e.cancel != nil {
+		e.cancel()
+	}
+
+	return ok
+}
+
+// Jobs 返回所有任务的状态
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		e.mu.Lock()
+		infos = append(infos, JobInfo{Name: e.name, Next: e.next, Running: e.running})
+		e.mu.Unlock()
+	}
+
+	return infos
+}
+
+// Start 开始调度, ctx 结束时等同于调用 Stop(但不等待). 重复调用无效.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, e := range s.entries {
+		s.run(e)
+	}
+}
+
+// Stop 停止调度, 取消所有任务的 ctx, 并等待正在进行的执行结束. 停止后不能再次 Start.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	} else {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.cancel()
+	}
+
+	dones := make([]chan struct{}, 0, len(s.entries))
+	for _, e := range s.entries {
+		if e.done != nil {
+			dones = append(dones, e.done)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, done := range dones {
+		<-done
+	}
+}
+
+// run 为任务启动调度协程, 调用方需持有 mu
+func (s *Scheduler) run(e *entry) {
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(s.ctx)
+	e.done = make(chan struct{})
+
+	gosafe.Go(func() {
+		defer close(e.done)
+
+		if e.opts.immediate && !s.exec(ctx, e) {
+			return
+		}
+
+		for {
+			next := e.schedule.Next(time.Now())
+			if next.IsZero() {
+				s.report(e.name, ErrorNoNextRun)
+				return
+			}
+
+			e.mu.Lock()
+			e.next = next
+			e.mu.Unlock()
+
+			wait := time.Until(next)
+			if e.opts.jitter > 0 {
+				wait += rand.N(e.opts.jitter)
+			}
+			if !sleep(ctx, wait) || !s.exec(ctx, e) {
+				return
+			}
+		}
+	})
+}
+
+// exec 执行一次任务, 执行是同步的, 因此同一个任务不会重叠. ctx 已经结束时不执行并返回 false
+func (s *Scheduler) exec(ctx context.Context, e *entry) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	e.mu.Lock()
+	e.next, e.running = time.Time{}, true
+	e.mu.Unlock()
+
+	var err error
+	if perr := gosafe.Run(func() { err = e.job(ctx) }); perr != nil {
+		err = perr
+	}
+
+	e.mu.Lock()
+	e.running = false
+	e.mu.Unlock()
+
+	if err != nil {
+		s.report(e.name, err)
+	}
+
+	return ctx.Err() == nil
+}
+
+func (s *Scheduler) report(name string, err error) {
+	if s.opts.onError != nil {
+		s.opts.onError(name, err)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/puresnr/go/gosafe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestScheduler(t *testing.T) {
+	gosafe.TrackTest(t)
+
+	var mu sync.Mutex
+	var errs []error
+	s := New(WithErrorHandler(func(name string, err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+
+	var runs, cur, overlap atomic.Int64
+	require.NoError(t, s.Every("slow", time.Millisecond, func(ctx context.Context) error {
+		if cur.Inc() > 1 {
+			overlap.Inc()
+		}
+		defer cur.Dec()
+		runs.Inc()
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, WithImmediate()))
+	assert.Equal(t, ErrorDuplicateJob, s.Every("slow", time.Second, func(context.Context) error { return nil }))
+	assert.Equal(t, ErrorInvalidJob, s.Every("zero", 0, func(context.Context) error { return nil }))
+	assert.Equal(t, ErrorInvalidJob, s.Every("negative", -time.Second, func(context.Context) error { return nil }))
+	assert.Equal(t, ErrorInvalidJob, s.Every("nil", time.Second, nil))
+	assert.Equal(t, ErrorInvalidJob, s.Add("nil schedule", nil, func(context.Context) error { return nil }))
+
+	s.Start(context.Background())
+
+	fail := errors.New("fail")
+	require.NoError(t, s.Every("failing", 5*time.Millisecond, func(context.Context) error { return fail }, WithJitter(time.Millisecond)))
+	require.NoError(t, s.Every("panicking", time.Hour, func(context.Context) error { panic("boom") }, WithImmediate()))
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(0), overlap.Load())
+	assert.Len(t, s.Jobs(), 3)
+
+	assert.True(t, s.Remove("failing"))
+	assert.False(t, s.Remove("failing"))
+
+	s.Stop()
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, errs, fail)
+	var pe *gosafe.PanicError
+	assert.True(t, func() bool {
+		for _, err := range errs {
+			if errors.As(err, &pe) {
+				return true
+			}
+		}
+		return false
+	}())
+}
@@ -0,0 +1,154 @@
+// Package trimpath 裁剪调用栈中的文件路径, 去掉构建机器上的绝对路径前缀. perror 和 gosafe 共用这里的设置
+package trimpath
+
+import (
+	"bytes"
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// Mode 决定文件路径的裁剪方式, 默认 None
+type Mode int
+
+const (
+	None       Mode = iota // 保留完整的构建路径
+	ModuleRoot             // 主模块内的文件使用相对于模块根目录的路径, 其他文件按 GOPATH 处理
+	GOPATH                 // 去掉 GOPATH/src 和 GOPATH/pkg/mod 前缀, 即从 import path 开始
+)
+
+type config struct {
+	mode    Mode
+	root    string   // 主模块根目录, 以 "/" 结尾
+	gopaths []string // GOPATH/src/ 和 GOPATH/pkg/mod/
+}
+
+var cfg atomic.Pointer[config]
+
+// SetMode 设置文件路径的裁剪方式, 返回之前的方式, 可以在运行中修改.
+// ModuleRoot 时根据调用 SetMode 的位置识别主模块根目录, 因此应当在主模块中(通常是 main 或 init 中) 调用:
+// 优先根据构建信息中的模块路径推算, 失败时从调用位置所在目录向上查找 go.mod; 都失败时只按 GOPATH 处理.
+func SetMode(mode Mode) Mode { return setMode(mode, 0) }
+
+// SetModeSkip 与 SetMode 相同, 但识别主模块根目录时使用的调用位置向上跳过 skip 层调用, 用于在其他包中转发 SetMode
+func SetModeSkip(mode Mode, skip int) Mode { return setMode(mode, max(skip, 0)) }
+
+func setMode(mode Mode, skip int) Mode {
+	c := &config{mode: mode}
+	if mode != None {
+		c.gopaths = gopathPrefixes()
+	}
+	if mode == ModuleRoot {
+		if pc, file, _, ok := runtime.Caller(skip + 2); ok {
+			if root := moduleRoot(pc, file); root != "" {
+				c.root = strings.TrimSuffix(root, "/") + "/"
+			}
+		}
+	}
+
+	if old := cfg.Swap(c); old != nil {
+		return old.mode
+	}
+
+	return None
+}
+
+// Path 按 SetMode 设置的方式裁剪 file, 不匹配任何前缀时原样返回
+func Path(file string) string {
+	c := cfg.Load()
+	if c == nil || c.mode == None {
+		return file
+	}
+
+	if c.root != "" && strings.HasPrefix(file, c.root) {
+		return file[len(c.root):]
+	}
+	for _, p := range c.gopaths {
+		if strings.HasPrefix(file, p) {
+			return file[len(p):]
+		}
+	}
+
+	return file
+}
+
+// Stack 按 SetMode 设置的方式裁剪 debug.Stack 格式的调用栈中的文件路径, 返回新的切片; 没有开启裁剪时原样返回
+func Stack(stack []byte) []byte {
+	if c := cfg.Load(); c == nil || c.mode == None {
+		return stack
+	}
+
+	lines := bytes.Split(stack, []byte("\n"))
+	for i, line := range lines {
+		// 文件所在的行形如 "\t/path/to/file.go:123 +0x1a"
+		if len(line) == 0 || line[0] != '\t' {
+			continue
+		}
+		if colon := bytes.LastIndexByte(line, ':'); colon > 1 {
+			file := string(line[1:colon])
+			if trimmed := Path(file); trimmed != file {
+				lines[i] = append([]byte("\t"+trimmed), line[colon:]...)
+			}
+		}
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func gopathPrefixes() []string {
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+
+	var prefixes []string
+	for _, p := range filepath.SplitList(gopath) {
+		if p = filepath.ToSlash(p); p != "" {
+			p = strings.TrimSuffix(p, "/")
+			prefixes = append(prefixes, p+"/src/", p+"/pkg/mod/")
+		}
+	}
+
+	return prefixes
+}
+
+// moduleRoot 根据调用位置推算主模块根目录, 失败时返回 ""
+func moduleRoot(pc uintptr, file string) string {
+	dir := path.Dir(file)
+
+	// 调用位置所在包的 import path 为 "模块路径/rel" 时, 其目录为 "根目录/rel"
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			pkg := funcPackage(fn.Name())
+			if pkg == bi.Main.Path || strings.HasPrefix(pkg, bi.Main.Path+"/") {
+				if rel := strings.TrimPrefix(pkg, bi.Main.Path); strings.HasSuffix(dir, rel) {
+					return strings.TrimSuffix(dir, rel)
+				}
+			}
+		}
+	}
+
+	for d := dir; ; d = path.Dir(d) {
+		if _, err := os.Stat(filepath.Join(filepath.FromSlash(d), "go.mod")); err == nil {
+			return d
+		}
+		if path.Dir(d) == d {
+			return ""
+		}
+	}
+}
+
+// funcPackage 从 runtime 的函数名中取出包的 import path, 如 "github.com/a/b.(*T).M" 返回 "github.com/a/b"
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+
+	return name
+}
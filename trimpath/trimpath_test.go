@@ -0,0 +1,34 @@
+package trimpath
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	prev := SetMode(None)
+	t.Cleanup(func() { SetMode(prev) })
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	file := filepath.ToSlash(filepath.Join(wd, "trimpath_test.go"))
+	stack := []byte("goroutine 1 [running]:\nmain.f()\n\t" + file + ":12 +0x1d\n")
+	assert.Equal(t, file, Path(file))
+	assert.Equal(t, stack, Stack(stack))
+
+	assert.Equal(t, None, SetMode(ModuleRoot))
+	assert.Equal(t, "trimpath/trimpath_test.go", Path(file))
+	assert.Equal(t, "goroutine 1 [running]:\nmain.f()\n\ttrimpath/trimpath_test.go:12 +0x1d\n", string(Stack(stack)))
+
+}
+
+func TestFuncPackage(t *testing.T) {
+	assert.Equal(t, "github.com/a/b", funcPackage("github.com/a/b.(*T).M"))
+	assert.Equal(t, "github.com/a/b.c/d", funcPackage("github.com/a/b.c/d.F.func1"))
+	assert.Equal(t, "main", funcPackage("main.main"))
+	assert.Equal(t, "x", funcPackage("x"))
+}